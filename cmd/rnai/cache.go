@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/ai"
+	"github.com/maltehedderich/rename-ai/internal/adapters/cache"
+	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/config"
+)
+
+var (
+	noCache  bool
+	pruneAll bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the suggestion cache",
+	Long: `Inspect and clean the suggestion cache.
+
+AI suggestions are cached by file content, provider, model, prompt version,
+template and instructions, so re-running rnai on an unchanged file is free.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the number and size of cached suggestions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		console := ui.NewConsoleUI()
+		c, err := openCache("")
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+		st, err := c.Stats()
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+
+		console.Info(fmt.Sprintf("Location: %s", c.Dir()))
+		console.Info(fmt.Sprintf("Entries:  %d (%d expired)", st.Entries, st.Expired))
+		console.Info(fmt.Sprintf("Size:     %.1f KiB", float64(st.Bytes)/1024))
+		if !st.Oldest.IsZero() {
+			console.Info(fmt.Sprintf("Oldest:   %s", st.Oldest.Local().Format(time.DateTime)))
+			console.Info(fmt.Sprintf("Newest:   %s", st.Newest.Local().Format(time.DateTime)))
+		}
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired suggestions (or all with --all)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		console := ui.NewConsoleUI()
+		c, err := openCache("")
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+		removed, err := c.Prune(pruneAll)
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+		console.Info(fmt.Sprintf("Removed %d cached suggestions.", removed))
+	},
+}
+
+// openCache returns the cache at RNAI_CACHE (or the default location) for the
+// given namespace, expiring entries after cache_ttl.
+func openCache(namespace string) (*cache.Cache, error) {
+	dir := viper.GetString("cache")
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	ttl := cache.DefaultTTL
+	if viper.IsSet(config.KeyCacheTTL) {
+		ttl = viper.GetDuration(config.KeyCacheTTL)
+	}
+	return cache.New(dir, namespace, ttl), nil
+}
+
+// cacheNamespace separates suggestions of different providers, models and
+// prompt versions.
+func cacheNamespace(modelName string) string {
+	return fmt.Sprintf("%s/%s/prompt-v%d", viper.GetString(config.KeyProvider), modelName, ai.PromptVersion)
+}
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&pruneAll, "all", false, "Remove every cached suggestion, not only expired ones")
+	cacheCmd.AddCommand(cacheStatsCmd, cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
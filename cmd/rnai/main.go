@@ -2,128 +2,245 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/maltehedderich/rename-ai/internal/adapters/ai"
+	"github.com/maltehedderich/rename-ai/internal/adapters/editor"
 	"github.com/maltehedderich/rename-ai/internal/adapters/fs"
+	"github.com/maltehedderich/rename-ai/internal/adapters/journal"
 	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/config"
 	"github.com/maltehedderich/rename-ai/internal/domain"
+	"github.com/maltehedderich/rename-ai/internal/ratelimit"
 )
 
 var (
-	dryRun bool
-	style  string
-	model  string
+	dryRun      bool
+	style       string
+	tmplStr     string
+	model       string
+	recursive   bool
+	include     []string
+	exclude     []string
+	jobs        int
+	rpm         int
+	tpm         int
+	profile     string
+	provider    string
+	baseURL     string
+	uploadMB    int
+	candidates  int
+	minConf     float64
+	organize    string
+	unicodeMode string
+	normExt     bool
+	fixExt      bool
+	onCollision string
+	edit        bool
+	interactive bool
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "rnai [file]",
+	Use:   "rnai [paths...]",
 	Short: "Rename files using GenAI",
-	Args:  cobra.ExactArgs(1),
+	Long: `Rename files using GenAI.
+
+Paths may be files, directories or glob patterns. Directories are expanded
+to the files they contain (use --recursive to descend into subdirectories).`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		filePath := args[0]
-		ctx := context.Background()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
 		// 1. Initialize Adapters
-		console := ui.NewConsoleUI()
-		fileSys := fs.NewOsFileSystem()
+		console := mustOutput()
+		fileSys := newFileSystem(console)
+		bindBatchFlags(cmd)
 
-		// Validation
-		if !fileSys.Exists(filePath) {
-			console.Error(fmt.Sprintf("File not found: %s", filePath))
-			os.Exit(1)
+		reqs, err := collectRequests(fileSys, args)
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
 		}
-
-		// Config / Auth
-		key := viper.GetString("GEMINI_API_KEY")
-		if key == "" {
-			console.Error("GEMINI_API_KEY invalid. Please set GEMINI_API_KEY environment variable.")
-			os.Exit(1)
+		policy, opts, err := policyOptions()
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
 		}
-
-		// Get model from viper (flag or env)
-		modelName := viper.GetString("model")
-		if modelName == "" {
-			modelName = "gemini-flash-latest" // Default fallback if not set by flag or env (though flag default handles this)
+		// Fail before anything is analyzed rather than block on, or fail at, a prompt.
+		if interactive && policy != nil {
+			console.Error("--interactive cannot be combined with --yes or --auto-apply-if")
+			exit(console, 1)
 		}
-
-		console.PrintModelInfo(modelName)
-
-		aiClient, err := ai.NewGeminiProvider(ctx, key, modelName)
-		if err != nil {
-			console.Error(fmt.Sprintf("Failed to initialize AI client: %v", err))
-			os.Exit(1)
+		if err := promptError(); err != nil {
+			switch {
+			case edit || interactive:
+				console.Error(fmt.Sprintf("--edit and --interactive need a terminal: %v", err))
+				exit(console, 1)
+			case policy == nil && !dryRun:
+				console.Error(fmt.Sprintf("Cannot ask for confirmation: %v. Pass --yes, --auto-apply-if or --dry-run.", err))
+				exit(console, 1)
+			}
 		}
 
 		// 2. Execution Flow
-
-		mimeType, err := fileSys.GetMimeType(filePath)
+		svc, _, err := newService(ctx, console, fileSys, append(opts, app.WithEditor(editor.New()))...)
 		if err != nil {
-			console.Error(fmt.Sprintf("Failed to detect mime type: %v", err))
-			os.Exit(1)
+			console.Error(err.Error())
+			exit(console, 1)
 		}
-		console.PrintDetectedType(mimeType)
 
-		if err := domain.IsAllowedMimeType(mimeType); err != nil {
-			console.Error(fmt.Sprintf("Validation failed: %v", err))
-			os.Exit(1)
+		results := svc.Propose(ctx, reqs)
+		if ctx.Err() != nil {
+			console.Error("Interrupted. No files were renamed.")
+			exit(console, 130)
 		}
+		// Restore default signal handling so Ctrl-C aborts the confirmation prompt.
+		stop()
 
-		console.PrintAnalyzing(filepath.Base(filePath))
-		content, err := fileSys.ReadFile(filePath)
-		if err != nil {
-			console.Error(fmt.Sprintf("Failed to read file: %v", err))
-			os.Exit(1)
+		// 3. User Interaction
+		if edit {
+			if err := svc.Edit(results); err != nil {
+				console.Error(err.Error())
+				exit(console, 1)
+			}
+		}
+		var confirmed bool
+		if interactive {
+			// ctx is cancelled by now; regenerating ends with the process on Ctrl-C.
+			confirmed, err = svc.ReviewEach(context.Background(), results)
+		} else {
+			confirmed, err = svc.Review(results)
 		}
-
-		// Generate Name
-		currentExt := filepath.Ext(filePath)
-		newName, reasoning, err := aiClient.GenerateName(ctx, content, mimeType, currentExt)
 		if err != nil {
-			console.Error(fmt.Sprintf("AI Generation failed: %v", err))
-			os.Exit(1)
+			console.Error(err.Error())
+			console.PrintResults(results)
+			exit(console, 1)
 		}
+		if confirmed {
+			svc.Apply(results)
+			if n := replaced(results); n > 0 {
+				console.Info(fmt.Sprintf("%d existing files were replaced; rnai undo cannot restore them.", n))
+			} else if app.Count(results, domain.StatusRenamed) > 0 {
+				console.Info("Undo with: rnai undo --last")
+			}
+		}
+		console.PrintResults(results)
+		if app.Count(results, domain.StatusFailed) > 0 {
+			exit(console, 1)
+		}
+		_ = console.Close()
+	},
+}
 
-		// Sanitize & Domain Logic
-		safeName := domain.SanitizeFilename(newName)
-
-		// Collision Check
-		// Need absolute path for checking existence in the same dir
-		dir := filepath.Dir(filePath)
-		finalName := domain.ResolveCollision(safeName, func(name string) bool {
-			return fileSys.Exists(filepath.Join(dir, name))
-		})
-
-		// 3. User Interaction
-		console.PrintProposal(filepath.Base(filePath), finalName, reasoning)
+// collectRequests expands the path arguments according to the batch flags.
+func collectRequests(fileSys *fs.OsFileSystem, args []string) ([]domain.RenameRequest, error) {
+	files, err := fileSys.CollectFiles(args, fs.CollectOptions{
+		Recursive: recursive,
+		Include:   include,
+		Exclude:   exclude,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("No files matched the given paths and filters.")
+	}
+	reqs := make([]domain.RenameRequest, len(files))
+	for i, f := range files {
+		reqs[i] = domain.RenameRequest{OriginalPath: f}
+	}
+	return reqs, nil
+}
 
-		if dryRun {
-			console.PrintDryRun()
-			return
+// replaced returns how many renamed files replaced an existing file.
+func replaced(results []domain.RenameResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == domain.StatusRenamed && r.Replaces {
+			n++
 		}
+	}
+	return n
+}
 
-		confirm, err := console.Confirm("Rename?")
+// newService validates the configuration and builds the application service
+// from it, returning it together with the model name. The given options are
+// applied last.
+func newService(ctx context.Context, console output, fileSys *fs.OsFileSystem, extra ...app.Option) (*app.Service, string, error) {
+	nameStyle, err := domain.ParseStyle(viper.GetString(config.KeyStyle))
+	if err != nil {
+		return nil, "", err
+	}
+	tmpl, err := domain.ParseTemplate(viper.GetString(config.KeyTemplate))
+	if err != nil {
+		return nil, "", err
+	}
+	mode, err := domain.ParseUnicodeMode(viper.GetString(config.KeyUnicode))
+	if err != nil {
+		return nil, "", err
+	}
+	translit, err := domain.NewTransliterator(mode, viper.GetStringMapString(config.KeyTransliterate))
+	if err != nil {
+		return nil, "", err
+	}
+	collision, err := domain.ParseCollisionStrategy(viper.GetString(config.KeyOnCollision))
+	if err != nil {
+		return nil, "", err
+	}
+	if root := viper.GetString(config.KeyOrganize); root != "" {
+		taxonomy, err := domain.ParseTaxonomy(viper.GetStringSlice(config.KeyTaxonomy))
 		if err != nil {
-			console.Error(fmt.Sprintf("Input error: %v", err))
-			os.Exit(1)
+			return nil, "", err
 		}
+		extra = append(extra, app.WithTaxonomy(root, taxonomy))
+	}
 
-		if confirm {
-			newPath := filepath.Join(dir, finalName)
-			if err := fileSys.Rename(filePath, newPath); err != nil {
-				console.Error(fmt.Sprintf("Rename failed: %v", err))
-				os.Exit(1)
-			}
-			console.PrintSuccess(finalName)
-		} else {
-			console.PrintCancelled()
+	aiClient, modelName, err := newProvider(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	console.PrintModelInfo(modelName)
+
+	jrnl, err := openJournal()
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := []app.Option{
+		app.WithJournal(jrnl.Recorder(journal.NewRunID(), modelName)),
+		app.WithLimiter(ratelimit.New(viper.GetInt("rpm"), viper.GetInt("tpm"))),
+		app.WithJobs(viper.GetInt("jobs")),
+		app.WithStyle(nameStyle),
+		app.WithTemplate(tmpl),
+		app.WithTransliterator(translit),
+		app.WithExtensionPolicy(domain.ExtensionPolicy{
+			Aliases:     viper.GetBool(config.KeyNormalizeExt),
+			FixFromMime: viper.GetBool(config.KeyFixExt),
+		}),
+		app.WithCollisionStrategy(collision),
+		app.WithAllowedTypes(viper.GetStringSlice(config.KeyAllowedTypes)),
+		app.WithInstructions(viper.GetString(config.KeyPrompt)),
+		app.WithCandidates(viper.GetInt(config.KeyCandidates)),
+		app.WithMinConfidence(viper.GetFloat64(config.KeyMinConfidence)),
+		app.WithDryRun(dryRun),
+	}
+	if !noCache {
+		c, err := openCache(cacheNamespace(modelName))
+		if err != nil {
+			return nil, "", err
 		}
-	},
+		opts = append(opts, app.WithCache(c))
+	}
+	return app.NewService(fileSys, aiClient, console, append(opts, extra...)...), modelName, nil
 }
 
 func main() {
@@ -136,12 +253,81 @@ func main() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Simulate rename without executing")
-	rootCmd.PersistentFlags().StringVar(&style, "style", "kebab", "Naming style (kebab, snake)")
-	rootCmd.PersistentFlags().StringVar(&model, "model", "gemini-flash-latest", "Gemini model to use")
-	_ = viper.BindPFlag("model", rootCmd.PersistentFlags().Lookup("model"))
+	addBatchFlags(rootCmd)
+	addOutputFlag(rootCmd)
+	addPolicyFlags(rootCmd, true)
+	rootCmd.Flags().BoolVarP(&edit, "edit", "e", false, "Edit the proposed names in $EDITOR before confirming")
+	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Review each proposal: accept, skip, edit, regenerate with a hint, accept all or quit")
+	rootCmd.PersistentFlags().StringVar(&style, "style", "kebab", "Naming style (kebab, snake, camel, pascal, title, preserve)")
+	rootCmd.PersistentFlags().StringVar(&unicodeMode, "unicode", string(domain.UnicodeTranslit), "Non-ASCII characters in names: translit (Müller -> mueller, CJK kept), ascii (transliterate, drop the rest) or keep")
+	rootCmd.PersistentFlags().BoolVar(&normExt, "normalize-ext", false, "Use the canonical spelling of extensions, e.g. .jpg for .jpeg and .yaml for .yml")
+	rootCmd.PersistentFlags().BoolVar(&fixExt, "fix-ext", true, "Replace an extension that contradicts the detected file type, e.g. .jpg on a PNG image")
+	rootCmd.PersistentFlags().StringVar(&onCollision, "on-collision", string(domain.CollisionCounter), "What to do when a proposed name is taken: counter, hash, skip, ask, dedupe or keep-newer")
+	rootCmd.PersistentFlags().StringVar(&tmplStr, "template", domain.DefaultTemplate, "Filename template, e.g. '{date:2006-01-02}_{category}_{subject}{ext}'")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "gemini", "AI provider (gemini, openai, ollama)")
+	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Base URL of the OpenAI-compatible API or Ollama server")
+	rootCmd.PersistentFlags().IntVar(&uploadMB, "upload-threshold", ai.DefaultUploadThreshold>>20, "Upload files larger than this many MB through the Gemini File API instead of inline")
+	rootCmd.PersistentFlags().StringVar(&model, "model", "", "Model to use (default depends on --provider)")
+	rootCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Named profile from the config files to apply")
+	rootCmd.PersistentFlags().IntVar(&candidates, "candidates", 1, "Number of ranked name candidates to request per file; more than one shows a picker")
+	rootCmd.PersistentFlags().Float64Var(&minConf, "min-confidence", 0, "Leave files untouched whose proposal has a confidence below this value (0-1)")
+	rootCmd.PersistentFlags().StringVar(&organize, "organize", "", "Move files into the folders of the configured taxonomy below this directory")
+
+	// Flags take precedence over environment variables, profiles and config files.
+	_ = viper.BindPFlag(config.KeyProvider, rootCmd.PersistentFlags().Lookup("provider"))
+	_ = viper.BindPFlag(config.KeyBaseURL, rootCmd.PersistentFlags().Lookup("base-url"))
+	_ = viper.BindPFlag("upload_threshold", rootCmd.PersistentFlags().Lookup("upload-threshold"))
+	_ = viper.BindPFlag(config.KeyModel, rootCmd.PersistentFlags().Lookup("model"))
+	_ = viper.BindPFlag(config.KeyStyle, rootCmd.PersistentFlags().Lookup("style"))
+	_ = viper.BindPFlag(config.KeyTemplate, rootCmd.PersistentFlags().Lookup("template"))
+	_ = viper.BindPFlag(config.KeyUnicode, rootCmd.PersistentFlags().Lookup("unicode"))
+	_ = viper.BindPFlag(config.KeyNormalizeExt, rootCmd.PersistentFlags().Lookup("normalize-ext"))
+	_ = viper.BindPFlag(config.KeyFixExt, rootCmd.PersistentFlags().Lookup("fix-ext"))
+	_ = viper.BindPFlag(config.KeyOnCollision, rootCmd.PersistentFlags().Lookup("on-collision"))
+	_ = viper.BindPFlag(config.KeyCandidates, rootCmd.PersistentFlags().Lookup("candidates"))
+	_ = viper.BindPFlag(config.KeyMinConfidence, rootCmd.PersistentFlags().Lookup("min-confidence"))
+	_ = viper.BindPFlag(config.KeyOrganize, rootCmd.PersistentFlags().Lookup("organize"))
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+}
+
+// addBatchFlags registers the flags of commands that analyze a batch of files.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Descend into subdirectories of directory arguments")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "Only process files whose name matches one of these globs (e.g. '*.pdf')")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip files whose name matches one of these globs")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 4, "Number of files analyzed concurrently")
+	cmd.Flags().IntVar(&rpm, "rpm", 0, "Maximum AI requests per minute (0 = unlimited)")
+	cmd.Flags().IntVar(&tpm, "tpm", 0, "Maximum estimated AI tokens per minute (0 = unlimited)")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Neither read nor write cached AI suggestions")
+}
+
+// bindBatchFlags makes the batch flags of the running command take precedence
+// over the configuration files.
+func bindBatchFlags(cmd *cobra.Command) {
+	for _, name := range []string{"jobs", "rpm", "tpm"} {
+		_ = viper.BindPFlag(name, cmd.Flags().Lookup(name))
+	}
 }
 
 func initConfig() {
-	viper.AutomaticEnv() // Read from env variables
-	_ = viper.BindEnv("model", "GEMINI_MODEL")
+	// Read only the documented variables from the environment, so that generic
+	// names such as PROMPT or STYLE cannot leak into the configuration.
+	_ = viper.BindEnv("GEMINI_API_KEY")
+	_ = viper.BindEnv(config.KeyModel, "GEMINI_MODEL")
+	_ = viper.BindEnv("OPENAI_API_KEY")
+	_ = viper.BindEnv("OPENAI_BASE_URL")
+	_ = viper.BindEnv("OLLAMA_HOST")
+	_ = viper.BindEnv("journal", "RNAI_JOURNAL")
+	_ = viper.BindEnv("cache", "RNAI_CACHE")
+	_ = viper.BindEnv("profile", "RNAI_PROFILE")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		ui.NewConsoleUI().Error(fmt.Sprintf("Failed to determine working directory: %v", err))
+		os.Exit(1)
+	}
+	if _, err := config.Load(viper.GetViper(), cwd, viper.GetString("profile")); err != nil {
+		ui.NewConsoleUI().Error(err.Error())
+		os.Exit(1)
+	}
 }
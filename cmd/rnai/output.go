@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/fs"
+	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+	"github.com/maltehedderich/rename-ai/internal/ports"
+)
+
+var outputFormat string
+
+// output is the user interface selected with --output.
+type output interface {
+	ports.UI
+	PrintModelInfo(model string)
+	// PrintProgress reports how far a large file has been copied to another
+	// filesystem.
+	PrintProgress(path string, copied, total int64)
+	// PrintResults reports the final status of every file.
+	PrintResults(results []domain.RenameResult)
+	// Close flushes anything not written yet.
+	Close() error
+}
+
+// newOutput returns the console, or a JSONUI for --output json and ndjson.
+func newOutput() (output, error) {
+	if outputFormat == ui.FormatText {
+		return ui.NewConsoleUI(), nil
+	}
+	return ui.NewJSONUI(outputFormat)
+}
+
+// mustOutput is newOutput for the start of a command: an unknown format ends
+// the process.
+func mustOutput() output {
+	out, err := newOutput()
+	if err != nil {
+		ui.NewConsoleUI().Error(err.Error())
+		os.Exit(1)
+	}
+	return out
+}
+
+// newFileSystem returns the filesystem, reporting the progress of slow moves
+// to out.
+func newFileSystem(out output) *fs.OsFileSystem {
+	fileSys := fs.NewOsFileSystem()
+	fileSys.SetProgress(out.PrintProgress)
+	return fileSys
+}
+
+// exit flushes out and ends the process with code.
+func exit(out output, code int) {
+	_ = out.Close()
+	os.Exit(code)
+}
+
+// structured reports whether the output is meant for programs, not people.
+func structured() bool {
+	return outputFormat != ui.FormatText
+}
+
+// addOutputFlag registers --output on a command that proposes or renames files.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "output", ui.FormatText, "Output format: text, json (one array at the end) or ndjson (one event per line); json formats never prompt")
+}
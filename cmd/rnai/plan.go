@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/journal"
+	"github.com/maltehedderich/rename-ai/internal/adapters/plan"
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/config"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+var planFile string
+
+var planCmd = &cobra.Command{
+	Use:   "plan [paths...]",
+	Short: "Write proposed renames to a plan file without renaming anything",
+	Long: `Write proposed renames to a plan file without renaming anything.
+
+The plan lists the original path, proposed name, reasoning and content hash of
+every file. Edit the proposed names or delete entries, then run
+'rnai apply <plan>' to execute exactly what is left.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		console := mustOutput()
+		fileSys := newFileSystem(console)
+		bindBatchFlags(cmd)
+
+		// A plan is written without asking anything.
+		if strategy, _ := domain.ParseCollisionStrategy(viper.GetString(config.KeyOnCollision)); strategy == domain.CollisionAsk {
+			console.Error("rnai plan cannot ask about taken names; use another --on-collision strategy")
+			exit(console, 1)
+		}
+		reqs, err := collectRequests(fileSys, args)
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+		svc, modelName, err := newService(ctx, console, fileSys)
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+
+		results := svc.Propose(ctx, reqs)
+		if ctx.Err() != nil {
+			console.Error("Interrupted. No plan was written.")
+			exit(console, 130)
+		}
+		console.PrintReviewTable(results)
+
+		p, err := plan.FromResults(results, modelName)
+		if err == nil {
+			err = plan.Write(planFile, p)
+		}
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+		console.Info(fmt.Sprintf("Wrote %d planned renames to %s. Review it, then run: rnai apply %s", len(p.Entries), planFile, planFile))
+		if app.Count(results, domain.StatusFailed) > 0 {
+			exit(console, 1)
+		}
+		_ = console.Close()
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan>",
+	Short: "Execute the renames of a plan file",
+	Long: `Execute the renames of a plan file written by 'rnai plan'.
+
+Entries are applied exactly as written, in order. An entry is refused if its
+file has changed since planning, if its proposed name is not a plain filename,
+or if that name is already taken. Applied renames can be reversed with
+'rnai undo'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		console := mustOutput()
+		fileSys := newFileSystem(console)
+
+		p, err := plan.Read(args[0])
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+		jrnl, err := openJournal()
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+
+		// The plan was reviewed when it was edited, so it is applied without asking.
+		svc := app.NewService(fileSys, nil, console,
+			app.WithJournal(jrnl.Recorder(journal.NewRunID(), p.Model)),
+			app.WithPolicy(app.ApplyAll),
+			app.WithExactNames(),
+			app.WithDryRun(dryRun),
+		)
+		results := p.Results()
+		svc.Verify(results)
+		confirmed, err := svc.Review(results)
+		if err != nil {
+			console.Error(err.Error())
+			console.PrintResults(results)
+			exit(console, 1)
+		}
+		if confirmed {
+			svc.Apply(results)
+			if app.Count(results, domain.StatusRenamed) > 0 {
+				console.Info("Undo with: rnai undo --last")
+			}
+		}
+		console.PrintResults(results)
+		if app.Count(results, domain.StatusFailed) > 0 {
+			exit(console, 1)
+		}
+		_ = console.Close()
+	},
+}
+
+func init() {
+	addBatchFlags(planCmd)
+	planCmd.Flags().StringVar(&planFile, "plan-file", "plan.json", "Plan file to write")
+	addOutputFlag(planCmd)
+	addOutputFlag(applyCmd)
+	rootCmd.AddCommand(planCmd, applyCmd)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/app"
+)
+
+var (
+	assumeYes   bool
+	autoApplyIf string
+	skipIfName  string
+)
+
+// addPolicyFlags registers the flags that decide without asking. Commands that
+// never ask leave out --yes.
+func addPolicyFlags(cmd *cobra.Command, yes bool) {
+	if yes {
+		cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply all proposals without asking")
+	}
+	cmd.Flags().StringVar(&autoApplyIf, "auto-apply-if", "", "Apply proposals meeting this condition without asking and skip the rest, e.g. 'confidence>=0.8'")
+	cmd.Flags().StringVar(&skipIfName, "skip-if-name-matches", "", "Leave files whose current name matches this regular expression alone, without analyzing them")
+}
+
+// policyOptions turns the policy flags into service options. The returned
+// policy is nil if the user is to be asked.
+func policyOptions() (app.Policy, []app.Option, error) {
+	var opts []app.Option
+	if skipIfName != "" {
+		re, err := regexp.Compile(skipIfName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --skip-if-name-matches: %w", err)
+		}
+		opts = append(opts, app.WithSkipNames(re))
+	}
+
+	var policy app.Policy
+	switch {
+	case assumeYes && autoApplyIf != "":
+		return nil, nil, errors.New("--yes and --auto-apply-if cannot be combined")
+	case assumeYes:
+		policy = app.ApplyAll
+	case autoApplyIf != "":
+		p, err := app.ParseCondition(autoApplyIf)
+		if err != nil {
+			return nil, nil, err
+		}
+		policy = p
+	}
+	if policy != nil {
+		opts = append(opts, app.WithPolicy(policy))
+	}
+	return policy, opts, nil
+}
+
+// promptError explains why the user cannot be asked, or returns nil if they can.
+func promptError() error {
+	if structured() {
+		return fmt.Errorf("--output %s never prompts", outputFormat)
+	}
+	if !ui.IsTerminal(os.Stdin) {
+		return errors.New("stdin is not a terminal")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/ai"
+	"github.com/maltehedderich/rename-ai/internal/config"
+	"github.com/maltehedderich/rename-ai/internal/ports"
+)
+
+// Supported values of --provider.
+const (
+	providerGemini = "gemini"
+	providerOpenAI = "openai"
+	providerOllama = "ollama"
+)
+
+// defaultModels is used when no model is configured for the selected provider.
+var defaultModels = map[string]string{
+	providerGemini: "gemini-flash-latest",
+	providerOpenAI: "gpt-4o-mini",
+	providerOllama: "gemma3",
+}
+
+// newProvider builds the AI provider selected by the configuration and returns
+// it together with the model name it uses. An explicit --base-url or base_url
+// takes precedence over the provider's own environment variable.
+func newProvider(ctx context.Context) (ports.AIProvider, string, error) {
+	name := viper.GetString(config.KeyProvider)
+	modelName := viper.GetString(config.KeyModel)
+	if modelName == "" {
+		modelName = defaultModels[name]
+	}
+
+	switch name {
+	case providerGemini:
+		key := viper.GetString("GEMINI_API_KEY")
+		if key == "" {
+			return nil, "", errors.New("GEMINI_API_KEY invalid. Please set GEMINI_API_KEY environment variable.")
+		}
+		p, err := ai.NewGeminiProvider(ctx, key, modelName,
+			ai.WithUploadThreshold(int64(viper.GetInt("upload_threshold"))<<20))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to initialize AI client: %w", err)
+		}
+		return p, modelName, nil
+	case providerOpenAI:
+		// The API key is optional: local stand-in servers usually do not need one.
+		return ai.NewOpenAIProvider(endpoint("OPENAI_BASE_URL"), viper.GetString("OPENAI_API_KEY"), modelName), modelName, nil
+	case providerOllama:
+		return ai.NewOllamaProvider(endpoint("OLLAMA_HOST"), modelName), modelName, nil
+	default:
+		return nil, "", fmt.Errorf("unknown provider %q (supported: %s, %s, %s)", name, providerGemini, providerOpenAI, providerOllama)
+	}
+}
+
+func endpoint(envKey string) string {
+	if url := viper.GetString(config.KeyBaseURL); url != "" {
+		return url
+	}
+	return viper.GetString(envKey)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/journal"
+	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+)
+
+var (
+	undoRunID string
+	undoLast  bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [--run ID | --last | path]",
+	Short: "Reverse renames recorded in the journal",
+	Long: `Reverse renames recorded in the journal.
+
+Select what to undo with exactly one of --run ID, --last (the most recent run
+that still has renames to undo) or the current path of a renamed file. A rename
+is only reversed if the file still has the content it had when it was renamed
+and its original name is free.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		console := ui.NewConsoleUI()
+		fileSys := newFileSystem(console)
+
+		selectors := len(args)
+		if undoRunID != "" {
+			selectors++
+		}
+		if undoLast {
+			selectors++
+		}
+		if selectors != 1 {
+			console.Error("Specify exactly one of --run ID, --last or a path.")
+			os.Exit(1)
+		}
+
+		jrnl, err := openJournal()
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+		entries, err := jrnl.Entries()
+		if err != nil {
+			console.Error(err.Error())
+			os.Exit(1)
+		}
+		pending := journal.Pending(entries)
+
+		var selected []journal.Entry
+		switch {
+		case undoLast:
+			selected = journal.Last(pending)
+		case undoRunID != "":
+			selected = journal.ByRun(pending, undoRunID)
+		default:
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				console.Error(err.Error())
+				os.Exit(1)
+			}
+			selected = journal.ByPath(pending, path)
+		}
+		if len(selected) == 0 {
+			console.Info("Nothing to undo.")
+			return
+		}
+
+		failed := 0
+		// Reverse in the opposite order of application so chained renames unwind correctly.
+		for i := len(selected) - 1; i >= 0; i-- {
+			e := selected[i]
+			if dryRun {
+				console.Info(fmt.Sprintf("Would rename %s -> %s", e.NewPath, e.OriginalPath))
+				continue
+			}
+			if err := jrnl.Undo(fileSys, e); err != nil {
+				console.Error(fmt.Sprintf("Cannot undo: %v", err))
+				failed++
+				continue
+			}
+			console.PrintSuccess(filepath.Base(e.OriginalPath))
+		}
+		if dryRun {
+			console.PrintDryRun()
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// openJournal returns the journal at RNAI_JOURNAL, or at the default location.
+func openJournal() (*journal.Journal, error) {
+	path := viper.GetString("journal")
+	if path == "" {
+		var err error
+		if path, err = journal.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return journal.New(path), nil
+}
+
+func init() {
+	undoCmd.Flags().StringVar(&undoRunID, "run", "", "Undo all renames of the given run ID")
+	undoCmd.Flags().BoolVar(&undoLast, "last", false, "Undo the most recent run")
+	rootCmd.AddCommand(undoCmd)
+}
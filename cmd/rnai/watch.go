@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/watch"
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+var (
+	watchSettle     = watch.DefaultSettle
+	watchQuarantine string
+	watchState      string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Rename files as they arrive in a directory",
+	Long: `Rename files as they arrive in a directory.
+
+Files already in the directory and every new file are renamed without asking,
+once their size and modification time have not changed for --settle; use
+--auto-apply-if to apply only some proposals. Files that cannot be renamed are
+moved to the quarantine directory. Processed content is recorded in a state
+file, so restarting the watcher does not process the same files again.
+Subdirectories and hidden files are ignored.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		console := mustOutput()
+		fileSys := newFileSystem(console)
+
+		dir := args[0]
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			console.Error(fmt.Sprintf("Not a directory: %s", dir))
+			exit(console, 1)
+		}
+		quarantine := watchQuarantine
+		if quarantine == "" {
+			quarantine = filepath.Join(dir, "quarantine")
+		}
+		statePath := watchState
+		if statePath == "" {
+			statePath = filepath.Join(dir, watch.StateFileName)
+		}
+		state, err := watch.OpenState(statePath)
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+
+		policy, opts, err := policyOptions()
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+		if policy == nil {
+			opts = append(opts, app.WithPolicy(app.ApplyAll))
+		}
+		svc, _, err := newService(ctx, console, fileSys, opts...)
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+
+		console.Info(fmt.Sprintf("Watching %s (Ctrl-C to stop)", dir))
+		err = watch.New(dir, watchSettle).Run(ctx, func(path string) {
+			hash, err := fileSys.HashFile(path)
+			if err != nil || state.Seen(hash) {
+				return
+			}
+
+			result, _ := svc.Rename(ctx, domain.RenameRequest{OriginalPath: path})
+			if ctx.Err() != nil {
+				return
+			}
+			console.PrintResults([]domain.RenameResult{result})
+			if dryRun {
+				return
+			}
+			if result.Status == domain.StatusFailed {
+				// Not recorded, so that the file is retried if it is moved back.
+				moved, err := watch.Quarantine(fileSys, path, quarantine)
+				if err != nil {
+					console.Error(fmt.Sprintf("Could not quarantine %s: %v", path, err))
+					return
+				}
+				console.Info(fmt.Sprintf("Moved %s to %s", result.OriginalName, moved))
+				return
+			}
+
+			newPath := path
+			if result.Status == domain.StatusRenamed {
+				newPath = result.NewPath()
+			}
+			if err := state.Add(watch.Record{ContentHash: hash, Path: newPath, Status: result.Status}); err != nil {
+				console.Error(err.Error())
+			}
+		})
+		if err != nil {
+			console.Error(err.Error())
+			exit(console, 1)
+		}
+		_ = console.Close()
+	},
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchSettle, "settle", watch.DefaultSettle, "How long a file must stay unchanged before it is processed")
+	watchCmd.Flags().StringVar(&watchQuarantine, "quarantine", "", "Directory for files that cannot be renamed (default <dir>/quarantine)")
+	watchCmd.Flags().StringVar(&watchState, "state", "", "State file recording processed files (default <dir>/"+watch.StateFileName+")")
+	watchCmd.Flags().BoolVar(&noCache, "no-cache", false, "Neither read nor write cached AI suggestions")
+	addOutputFlag(watchCmd)
+	addPolicyFlags(watchCmd, false)
+	rootCmd.AddCommand(watchCmd)
+}
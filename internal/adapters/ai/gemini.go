@@ -1,21 +1,56 @@
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
 )
 
+// DefaultUploadThreshold is the file size above which content is sent through
+// the File API instead of inline. Inline data is base64-encoded into a request
+// limited to 20MB, so larger files would be rejected.
+const DefaultUploadThreshold = 15 << 20
+
+const (
+	filePollInterval  = 2 * time.Second
+	fileDeleteTimeout = 30 * time.Second
+)
+
+// fileStore is the part of the genai File API the provider uses.
+type fileStore interface {
+	Upload(ctx context.Context, r io.Reader, config *genai.UploadFileConfig) (*genai.File, error)
+	Get(ctx context.Context, name string, config *genai.GetFileConfig) (*genai.File, error)
+	Delete(ctx context.Context, name string, config *genai.DeleteFileConfig) (*genai.DeleteFileResponse, error)
+}
+
 type GeminiProvider struct {
-	client *genai.Client
-	model  string
+	client          *genai.Client
+	files           fileStore
+	model           string
+	uploadThreshold int64
+	pollInterval    time.Duration
 }
 
-func NewGeminiProvider(ctx context.Context, apiKey string, modelName string) (*GeminiProvider, error) {
+// GeminiOption configures optional behaviour of a GeminiProvider.
+type GeminiOption func(*GeminiProvider)
+
+// WithUploadThreshold sets the size in bytes above which files are uploaded
+// through the File API (default DefaultUploadThreshold).
+func WithUploadThreshold(n int64) GeminiOption {
+	return func(p *GeminiProvider) { p.uploadThreshold = n }
+}
+
+func NewGeminiProvider(ctx context.Context, apiKey string, modelName string, opts ...GeminiOption) (*GeminiProvider, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey: apiKey,
 	})
@@ -24,70 +59,67 @@ func NewGeminiProvider(ctx context.Context, apiKey string, modelName string) (*G
 	}
 
 	// Use a capable model (no changes to model name passing, but verify default)
-	return &GeminiProvider{
-		client: client,
-		model:  modelName,
-	}, nil
+	p := &GeminiProvider{
+		client:          client,
+		files:           client.Files,
+		model:           modelName,
+		uploadThreshold: DefaultUploadThreshold,
+		pollInterval:    filePollInterval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
 }
 
-type aiResponse struct {
-	Filename  string `json:"filename"`
-	Reasoning string `json:"reasoning"`
+// aiCandidate is one answer of the model.
+type aiCandidate struct {
+	Fields     map[string]string `json:"fields"`
+	Reasoning  string            `json:"reasoning"`
+	Confidence float64           `json:"confidence"`
 }
 
-func (p *GeminiProvider) GenerateName(ctx context.Context, content []byte, mimeType string, currentExt string) (string, string, error) {
-	currentDate := time.Now().Format("2006-01-02")
-	prompt := fmt.Sprintf(`You are an intelligent file renaming assistant.
-		Context:
-		- Current Date: %s
-
-		Specific rules:
-		1. Analyze the attached content.
-		2. Summarize the content to identify its core subject and any relevant date.
-		3. Generate a filename adhering to the following structure: YYYY-MM-DD_Subject-Title%s
-		   - Always start with a date in ISO 8601 format (YYYY-MM-DD). If no specific date is found in the content, use the Current Date provided above as a fallback.
-		   - Use underscores (_) to separate the date from the subject/title.
-		   - Use hyphens (-) to separate words within the subject/title.
-		   - Alternatively, use CamelCase for the subject (e.g., BudgetReport) if appropriate.
-		   - Ensure the filename ends with the extension "%s".
-		4. Example: 2023-12-01_Budget-Report%s`, currentDate, currentExt, currentExt, currentExt)
-
-	// Define the schema for structured output
-	schema := map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"filename": map[string]any{
-				"type":        "string",
-				"description": "The generated filename, starting with YYYY-MM-DD, followed by an underscore and the subject/title, and ending with the correct extension.",
-			},
-			"reasoning": map[string]any{
-				"type":        "string",
-				"description": "Brief explanation of why this name was chosen.",
-			},
-		},
-		"required": []string{"filename", "reasoning"},
-	}
+// aiResponse is the model's best answer and, if requested, its alternatives.
+type aiResponse struct {
+	aiCandidate
+	Alternatives []aiCandidate `json:"alternatives,omitempty"`
+}
 
+func (p *GeminiProvider) GenerateName(ctx context.Context, req domain.NameRequest) (_ domain.NameSuggestion, err error) {
 	config := &genai.GenerateContentConfig{
 		ResponseMIMEType:   "application/json",
-		ResponseJsonSchema: schema,
-		SystemInstruction:  &genai.Content{Parts: []*genai.Part{{Text: prompt}}},
+		ResponseJsonSchema: responseSchema(req),
+		SystemInstruction:  &genai.Content{Parts: []*genai.Part{{Text: systemPrompt(req, time.Now())}}},
 	}
 
 	var part *genai.Part
-	if strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" || strings.Contains(mimeType, "xml") {
-		part = &genai.Part{Text: string(content)}
-	} else {
+	switch {
+	case domain.IsTextMimeType(req.MimeType):
+		part = &genai.Part{Text: string(req.Content)}
+	case int64(len(req.Content)) > p.uploadThreshold:
+		file, uploadErr := p.upload(ctx, req.Content, req.MimeType)
+		if file != nil {
+			defer func() { err = errors.Join(err, p.deleteFile(ctx, file.Name)) }()
+		}
+		if uploadErr != nil {
+			return domain.NameSuggestion{}, uploadErr
+		}
+		part = &genai.Part{FileData: &genai.FileData{
+			FileURI:  file.URI,
+			MIMEType: file.MIMEType,
+		}}
+	default:
 		part = &genai.Part{InlineData: &genai.Blob{
-			MIMEType: mimeType,
-			Data:     content,
+			MIMEType: req.MimeType,
+			Data:     req.Content,
 		}}
 	}
 
 	// Construct the content with the part
 	userContent := &genai.Content{
+		Role: genai.RoleUser,
 		Parts: []*genai.Part{
-			{Text: "Analyze the following file content and generate a filename."},
+			{Text: fileInstruction},
 			part,
 		},
 	}
@@ -95,18 +127,76 @@ func (p *GeminiProvider) GenerateName(ctx context.Context, content []byte, mimeT
 	resp, err := p.client.Models.GenerateContent(
 		ctx,
 		p.model,
-		[]*genai.Content{userContent},
+		append([]*genai.Content{userContent}, geminiHistory(req.History)...),
 		config,
 	)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate content: %w", err)
+		return domain.NameSuggestion{}, fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	return parseAIResponse(resp.Text(), req.Fields)
+}
+
+// geminiHistory replays earlier suggestions and the user's hints as turns of
+// the conversation.
+func geminiHistory(history []domain.Revision) []*genai.Content {
+	var contents []*genai.Content
+	for _, r := range history {
+		contents = append(contents,
+			genai.NewContentFromText(previousAnswer(r), genai.RoleModel),
+			genai.NewContentFromText(revisionPrompt(r), genai.RoleUser),
+		)
+	}
+	return contents
+}
+
+// upload sends content to the File API and waits until it has been processed.
+// The returned file is non-nil whenever the upload itself succeeded, even if an
+// error occurred afterwards, so that the caller can delete it.
+func (p *GeminiProvider) upload(ctx context.Context, content []byte, mimeType string) (*genai.File, error) {
+	file, err := p.files.Upload(ctx, bytes.NewReader(content), &genai.UploadFileConfig{MIMEType: mimeType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	return parseAIResponse(resp.Text())
+	for file.State == genai.FileStateProcessing {
+		select {
+		case <-ctx.Done():
+			return file, ctx.Err()
+		case <-time.After(p.pollInterval):
+		}
+		latest, err := p.files.Get(ctx, file.Name, nil)
+		if err != nil {
+			return file, fmt.Errorf("failed to check uploaded file: %w", err)
+		}
+		file = latest
+	}
+
+	if file.State == genai.FileStateFailed {
+		msg := "unknown error"
+		if file.Error != nil && file.Error.Message != "" {
+			msg = file.Error.Message
+		}
+		return file, fmt.Errorf("processing of uploaded file failed: %s", msg)
+	}
+	return file, nil
+}
+
+// deleteFile removes an uploaded file. It runs even if ctx has been cancelled,
+// so that no content is left behind on the server after an interrupted run.
+func (p *GeminiProvider) deleteFile(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fileDeleteTimeout)
+	defer cancel()
+	if _, err := p.files.Delete(ctx, name, nil); err != nil {
+		return fmt.Errorf("failed to delete uploaded file %s: %w", name, err)
+	}
+	return nil
 }
 
-// parseAIResponse handles the unmarshalling of the JSON response
-func parseAIResponse(respText string) (string, string, error) {
+// parseAIResponse handles the unmarshalling of the JSON response and checks that
+// every requested field was provided. Alternatives lacking a field are dropped;
+// the remaining candidates are ordered by confidence, best first.
+func parseAIResponse(respText string, fields []string) (domain.NameSuggestion, error) {
 	var result aiResponse
 	// Clean up potential markdown code blocks if the AI wraps the JSON (SDK might not, but safe to keep)
 	cleaned := strings.TrimSpace(respText)
@@ -120,12 +210,43 @@ func parseAIResponse(respText string) (string, string, error) {
 	cleaned = strings.TrimSpace(cleaned)
 
 	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
-		return "", "", fmt.Errorf("failed to parse AI response: %w (response: %s)", err, respText)
+		return domain.NameSuggestion{}, fmt.Errorf("failed to parse AI response: %w (response: %s)", err, respText)
+	}
+	if f, ok := missingField(result.aiCandidate, fields); !ok {
+		return domain.NameSuggestion{}, fmt.Errorf("AI response contained empty field %q", f)
+	}
+
+	candidates := []domain.NameSuggestion{result.suggestion()}
+	for _, alt := range result.Alternatives {
+		if _, ok := missingField(alt, fields); ok {
+			candidates = append(candidates, alt.suggestion())
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	best := candidates[0]
+	if len(candidates) > 1 {
+		best.Alternatives = candidates[1:]
 	}
+	return best, nil
+}
 
-	if result.Filename == "" {
-		return "", "", fmt.Errorf("AI response contained empty filename")
+// missingField returns the first requested field the candidate left empty.
+func missingField(c aiCandidate, fields []string) (string, bool) {
+	for _, f := range fields {
+		if strings.TrimSpace(c.Fields[f]) == "" {
+			return f, false
+		}
 	}
+	return "", true
+}
 
-	return result.Filename, result.Reasoning, nil
+func (c aiCandidate) suggestion() domain.NameSuggestion {
+	return domain.NameSuggestion{
+		Fields:     c.Fields,
+		Reasoning:  c.Reasoning,
+		Confidence: min(max(c.Confidence, 0), 1),
+	}
 }
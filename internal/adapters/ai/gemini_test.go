@@ -1,35 +1,48 @@
 package ai
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
 
 func TestParseAIResponse(t *testing.T) {
 	t.Parallel()
 
+	fields := []string{"date", "subject"}
 	tests := []struct {
 		name          string
 		input         string
-		wantFilename  string
+		wantFields    map[string]string
 		wantReasoning string
 		wantErr       bool
 	}{
 		{
 			name:          "valid json",
-			input:         `{"filename": "test-file.txt", "reasoning": "it's a test"}`,
-			wantFilename:  "test-file.txt",
+			input:         `{"fields": {"date": "2024-01-01", "subject": "Test File"}, "reasoning": "it's a test"}`,
+			wantFields:    map[string]string{"date": "2024-01-01", "subject": "Test File"},
 			wantReasoning: "it's a test",
 			wantErr:       false,
 		},
 		{
 			name:          "json with markdown code block",
-			input:         "```json\n{\"filename\": \"test-file.txt\", \"reasoning\": \"it's a test\"}\n```",
-			wantFilename:  "test-file.txt",
+			input:         "```json\n{\"fields\": {\"date\": \"2024-01-01\", \"subject\": \"Test File\"}, \"reasoning\": \"it's a test\"}\n```",
+			wantFields:    map[string]string{"date": "2024-01-01", "subject": "Test File"},
 			wantReasoning: "it's a test",
 			wantErr:       false,
 		},
 		{
 			name:          "json with plain code block",
-			input:         "```\n{\"filename\": \"test-file.txt\", \"reasoning\": \"it's a test\"}\n```",
-			wantFilename:  "test-file.txt",
+			input:         "```\n{\"fields\": {\"date\": \"2024-01-01\", \"subject\": \"Test File\"}, \"reasoning\": \"it's a test\"}\n```",
+			wantFields:    map[string]string{"date": "2024-01-01", "subject": "Test File"},
 			wantReasoning: "it's a test",
 			wantErr:       false,
 		},
@@ -39,19 +52,24 @@ func TestParseAIResponse(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "empty filename",
-			input:   `{"filename": "", "reasoning": "fail"}`,
+			name:    "empty field",
+			input:   `{"fields": {"date": "2024-01-01", "subject": " "}, "reasoning": "fail"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing field",
+			input:   `{"fields": {"date": "2024-01-01"}, "reasoning": "fail"}`,
 			wantErr: true,
 		},
 		{
-			name:    "missing filename field",
+			name:    "missing fields object",
 			input:   `{"reasoning": "fail"}`,
 			wantErr: true,
 		},
 		{
 			name:          "extra fields",
-			input:         `{"filename": "extra.txt", "reasoning": "ok", "other": "ignored"}`,
-			wantFilename:  "extra.txt",
+			input:         `{"fields": {"date": "2024-01-01", "subject": "Extra"}, "reasoning": "ok", "other": "ignored"}`,
+			wantFields:    map[string]string{"date": "2024-01-01", "subject": "Extra"},
 			wantReasoning: "ok",
 			wantErr:       false,
 		},
@@ -61,17 +79,216 @@ func TestParseAIResponse(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			gotFilename, gotReasoning, err := parseAIResponse(tc.input)
+			got, err := parseAIResponse(tc.input, fields)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("parseAIResponse() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
-			if gotFilename != tc.wantFilename {
-				t.Errorf("parseAIResponse() filename = %v, want %v", gotFilename, tc.wantFilename)
+			if tc.wantErr {
+				return
 			}
-			if gotReasoning != tc.wantReasoning {
-				t.Errorf("parseAIResponse() reasoning = %v, want %v", gotReasoning, tc.wantReasoning)
+			if !reflect.DeepEqual(got.Fields, tc.wantFields) {
+				t.Errorf("parseAIResponse() fields = %v, want %v", got.Fields, tc.wantFields)
+			}
+			if got.Reasoning != tc.wantReasoning {
+				t.Errorf("parseAIResponse() reasoning = %v, want %v", got.Reasoning, tc.wantReasoning)
 			}
 		})
 	}
 }
+
+func TestResponseSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := responseSchema(domain.NameRequest{Fields: []string{"date", "category", "client_name"}})
+	props := schema["properties"].(map[string]any)["fields"].(map[string]any)
+	if got := props["required"]; !reflect.DeepEqual(got, []string{"date", "category", "client_name"}) {
+		t.Errorf("required fields = %v", got)
+	}
+	fieldProps := props["properties"].(map[string]any)
+	if len(fieldProps) != 3 {
+		t.Fatalf("expected 3 field properties, got %d", len(fieldProps))
+	}
+	desc := fieldProps["client_name"].(map[string]any)["description"]
+	if desc != "The client name of the content in a few words." {
+		t.Errorf("generic description = %q", desc)
+	}
+}
+
+func TestResponseSchema_Categories(t *testing.T) {
+	t.Parallel()
+
+	schema := responseSchema(domain.NameRequest{Fields: []string{"category"}, Categories: []string{"Invoices", "Contracts"}})
+	fieldProps := schema["properties"].(map[string]any)["fields"].(map[string]any)["properties"].(map[string]any)
+	desc := fieldProps["category"].(map[string]any)["description"].(string)
+	if !strings.Contains(desc, "Invoices, Contracts") {
+		t.Errorf("category description = %q, want the taxonomy", desc)
+	}
+}
+
+func TestResponseSchema_Alternatives(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := responseSchema(domain.NameRequest{Fields: []string{"subject"}, Candidates: 1})["properties"].(map[string]any)["alternatives"]; ok {
+		t.Error("single candidate schema should not ask for alternatives")
+	}
+
+	schema := strictSchema(responseSchema(domain.NameRequest{Fields: []string{"subject"}, Candidates: 3}))
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"fields", "reasoning", "confidence", "alternatives"}) {
+		t.Errorf("required = %v", got)
+	}
+	items := schema["properties"].(map[string]any)["alternatives"].(map[string]any)["items"].(map[string]any)
+	if items["additionalProperties"] != false {
+		t.Error("strictSchema did not descend into the alternatives")
+	}
+}
+
+func TestParseAIResponse_Candidates(t *testing.T) {
+	t.Parallel()
+
+	input := `{"fields": {"subject": "Report"}, "reasoning": "a", "confidence": 0.5,
+		"alternatives": [
+			{"fields": {"subject": "Budget"}, "reasoning": "b", "confidence": 1.7},
+			{"fields": {"subject": ""}, "reasoning": "empty", "confidence": 0.9},
+			{"fields": {"subject": "Notes"}, "reasoning": "c", "confidence": 0.2}
+		]}`
+	got, err := parseAIResponse(input, []string{"subject"})
+	if err != nil {
+		t.Fatalf("parseAIResponse() error = %v", err)
+	}
+	if got.Fields["subject"] != "Budget" || got.Confidence != 1 {
+		t.Errorf("best = %v (%v), want Budget with confidence clamped to 1", got.Fields, got.Confidence)
+	}
+	var alts []string
+	for _, a := range got.Alternatives {
+		alts = append(alts, a.Fields["subject"])
+	}
+	if !reflect.DeepEqual(alts, []string{"Report", "Notes"}) {
+		t.Errorf("alternatives = %v, want [Report Notes]", alts)
+	}
+}
+
+// fakeFiles is an in-memory File API. Uploaded files report the given states
+// one by one on each Get.
+type fakeFiles struct {
+	states    []genai.FileState
+	uploaded  []byte
+	gets      int
+	deleted   []string
+	deleteCtx error // ctx.Err() seen by Delete
+	uploadErr error
+}
+
+func (f *fakeFiles) Upload(_ context.Context, r io.Reader, cfg *genai.UploadFileConfig) (*genai.File, error) {
+	if f.uploadErr != nil {
+		return nil, f.uploadErr
+	}
+	f.uploaded, _ = io.ReadAll(r)
+	return f.file(), nil
+}
+
+func (f *fakeFiles) Get(_ context.Context, name string, _ *genai.GetFileConfig) (*genai.File, error) {
+	f.gets++
+	return f.file(), nil
+}
+
+func (f *fakeFiles) Delete(ctx context.Context, name string, _ *genai.DeleteFileConfig) (*genai.DeleteFileResponse, error) {
+	f.deleted = append(f.deleted, name)
+	f.deleteCtx = ctx.Err()
+	return &genai.DeleteFileResponse{}, nil
+}
+
+func (f *fakeFiles) file() *genai.File {
+	state := f.states[min(f.gets, len(f.states)-1)]
+	file := &genai.File{Name: "files/abc", URI: "https://files/abc", MIMEType: "application/pdf", State: state}
+	if state == genai.FileStateFailed {
+		file.Error = &genai.FileStatus{Message: "corrupt"}
+	}
+	return file
+}
+
+func TestGeminiProvider_Upload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		files    *fakeFiles
+		cancel   bool
+		wantFile bool
+		wantErr  string
+		wantGets int
+	}{
+		{
+			name:     "active immediately",
+			files:    &fakeFiles{states: []genai.FileState{genai.FileStateActive}},
+			wantFile: true,
+		},
+		{
+			name:     "polls until active",
+			files:    &fakeFiles{states: []genai.FileState{genai.FileStateProcessing, genai.FileStateProcessing, genai.FileStateActive}},
+			wantFile: true,
+			wantGets: 2,
+		},
+		{
+			name:     "processing failed",
+			files:    &fakeFiles{states: []genai.FileState{genai.FileStateProcessing, genai.FileStateFailed}},
+			wantFile: true,
+			wantErr:  "corrupt",
+			wantGets: 1,
+		},
+		{
+			name:     "cancelled while processing",
+			files:    &fakeFiles{states: []genai.FileState{genai.FileStateProcessing}},
+			cancel:   true,
+			wantFile: true,
+			wantErr:  context.Canceled.Error(),
+		},
+		{
+			name:    "upload failed",
+			files:   &fakeFiles{uploadErr: errors.New("quota exceeded")},
+			wantErr: "quota exceeded",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				cancel()
+			}
+
+			p := &GeminiProvider{files: tc.files, pollInterval: time.Millisecond}
+			file, err := p.upload(ctx, []byte("%PDF"), "application/pdf")
+			if (file != nil) != tc.wantFile {
+				t.Errorf("upload() file = %v, want file %v", file, tc.wantFile)
+			}
+			if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("upload() error = %v, want %q", err, tc.wantErr)
+			}
+			if tc.files.gets != tc.wantGets {
+				t.Errorf("Get called %d times, want %d", tc.files.gets, tc.wantGets)
+			}
+		})
+	}
+}
+
+func TestGeminiProvider_DeleteFileAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	files := &fakeFiles{}
+	p := &GeminiProvider{files: files}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := p.deleteFile(ctx, "files/abc"); err != nil {
+		t.Fatalf("deleteFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(files.deleted, []string{"files/abc"}) {
+		t.Errorf("deleted = %v, want [files/abc]", files.deleted)
+	}
+	if files.deleteCtx != nil {
+		t.Errorf("Delete saw a cancelled context: %v", files.deleteCtx)
+	}
+}
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPOption configures the providers that talk to an HTTP API directly.
+type HTTPOption func(*httpClient)
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(h *httpClient) { h.client = c }
+}
+
+// httpClient sends JSON requests and decodes JSON responses.
+type httpClient struct {
+	client *http.Client
+	apiKey string
+}
+
+func newHTTPClient(apiKey string, opts []HTTPOption) httpClient {
+	h := httpClient{client: &http.Client{}, apiKey: apiKey}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	return h
+}
+
+// postJSON posts in as JSON to url and decodes the response into out. Non-2xx
+// responses become errors carrying the server's error message if it has one.
+func (h httpClient) postJSON(ctx context.Context, url string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if msg := errorMessage(respBody); msg != "" {
+			return fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message from an OpenAI-style {"error": {"message": ...}}
+// or Ollama-style {"error": "..."} body.
+func errorMessage(body []byte) string {
+	var nested struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &nested) == nil && nested.Error.Message != "" {
+		return nested.Error.Message
+	}
+	var flat struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &flat) == nil {
+		return flat.Error
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// DefaultOllamaBaseURL is the address of a local Ollama server.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// maxExtractedText caps the text sent for files the model cannot read directly.
+const maxExtractedText = 32 * 1024
+
+// ollamaImageTypes are the image formats Ollama accepts for vision models.
+var ollamaImageTypes = []string{"image/png", "image/jpeg", "image/webp"}
+
+// OllamaProvider talks to a local Ollama server, so no file content leaves the
+// machine. Images are sent to vision models as base64, text files as they are
+// and PDFs as the text extracted from them. For other files, images for models
+// without vision support and PDFs without text, the model only gets the
+// filename, type and size.
+type OllamaProvider struct {
+	http    httpClient
+	baseURL string
+	model   string
+
+	mu     sync.Mutex
+	vision *bool // cached result of the capability lookup
+}
+
+// NewOllamaProvider creates a provider for the Ollama server at baseURL. A host
+// without scheme, as in OLLAMA_HOST=127.0.0.1:11434, is accepted.
+func NewOllamaProvider(baseURL, modelName string, opts ...HTTPOption) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &OllamaProvider{
+		http:    newHTTPClient("", opts),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   modelName,
+	}
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Format   map[string]any  `json:"format"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+}
+
+type ollamaShowResponse struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+func (p *OllamaProvider) GenerateName(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	user, err := p.userMessage(ctx, req)
+	if err != nil {
+		return domain.NameSuggestion{}, err
+	}
+
+	in := ollamaChatRequest{
+		Model: p.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: systemPrompt(req, time.Now())},
+			user,
+		},
+		Format: responseSchema(req),
+	}
+	for _, r := range req.History {
+		in.Messages = append(in.Messages,
+			ollamaMessage{Role: "assistant", Content: previousAnswer(r)},
+			ollamaMessage{Role: "user", Content: revisionPrompt(r)},
+		)
+	}
+	var out ollamaChatResponse
+	if err := p.http.postJSON(ctx, p.baseURL+"/api/chat", in, &out); err != nil {
+		return domain.NameSuggestion{}, fmt.Errorf("failed to generate content: %w", err)
+	}
+	return parseAIResponse(out.Message.Content, req.Fields)
+}
+
+// userMessage attaches the file in the richest form the model accepts.
+func (p *OllamaProvider) userMessage(ctx context.Context, req domain.NameRequest) (ollamaMessage, error) {
+	mimeType := strings.TrimSpace(strings.Split(req.MimeType, ";")[0])
+	if domain.IsTextMimeType(mimeType) {
+		return ollamaMessage{Role: "user", Content: fileInstruction + "\n\n" + string(req.Content)}, nil
+	}
+
+	if slices.Contains(ollamaImageTypes, mimeType) {
+		vision, err := p.supportsVision(ctx)
+		if err != nil {
+			return ollamaMessage{}, err
+		}
+		if vision {
+			return ollamaMessage{
+				Role:    "user",
+				Content: fileInstruction,
+				Images:  []string{base64.StdEncoding.EncodeToString(req.Content)},
+			}, nil
+		}
+	}
+
+	if mimeType == "application/pdf" {
+		if text := pdfText(req.Content, maxExtractedText); text != "" {
+			return ollamaMessage{
+				Role:    "user",
+				Content: fmt.Sprintf("%s\n\nThe model cannot read PDF files directly. This is the text extracted from the file:\n\n%s", fileInstruction, text),
+			}, nil
+		}
+	}
+	return ollamaMessage{Role: "user", Content: metadataPrompt(req, mimeType)}, nil
+}
+
+// metadataPrompt describes a file the model cannot read by what is known about
+// it without its content.
+func metadataPrompt(req domain.NameRequest, mimeType string) string {
+	var b strings.Builder
+	b.WriteString(fileInstruction)
+	b.WriteString("\n\nThe model cannot read the content of this file. Base the name on what is known about it:\n")
+	if req.Name != "" {
+		fmt.Fprintf(&b, "\nFilename: %s", req.Name)
+	}
+	fmt.Fprintf(&b, "\nType: %s\nSize: %d bytes", mimeType, len(req.Content))
+	return b.String()
+}
+
+// supportsVision asks the server once whether the model accepts images.
+func (p *OllamaProvider) supportsVision(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.vision != nil {
+		return *p.vision, nil
+	}
+
+	var show ollamaShowResponse
+	if err := p.http.postJSON(ctx, p.baseURL+"/api/show", map[string]string{"model": p.model}, &show); err != nil {
+		return false, fmt.Errorf("failed to look up model %s: %w", p.model, err)
+	}
+	vision := slices.Contains(show.Capabilities, "vision")
+	p.vision = &vision
+	return vision, nil
+}
@@ -0,0 +1,230 @@
+package ai
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// newOllamaStandIn starts a local Ollama server whose model has the given
+// capabilities. Chat requests are recorded in chats.
+func newOllamaStandIn(t *testing.T, capabilities []string, chats chan<- ollamaChatRequest, shows *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			shows.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"capabilities": capabilities})
+		case "/api/chat":
+			var req ollamaChatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			chats <- req
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"message": map[string]any{
+					"role":    "assistant",
+					"content": `{"fields": {"date": "2024-03-01", "subject": "Employment Contract"}, "reasoning": "a contract"}`,
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllamaProvider_GenerateName(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\nIHDR\x00\x00 Camera Model XYZ \x00")
+	tests := []struct {
+		name         string
+		capabilities []string
+		mimeType     string
+		content      []byte
+		wantImages   bool
+		wantContent  string
+	}{
+		{
+			name:         "image to vision model",
+			capabilities: []string{"completion", "vision"},
+			mimeType:     "image/png",
+			content:      png,
+			wantImages:   true,
+		},
+		{
+			name:         "image to text-only model",
+			capabilities: []string{"completion"},
+			mimeType:     "image/png",
+			content:      png,
+			wantContent:  "Filename: photo.png\nType: image/png\nSize: 33 bytes",
+		},
+		{
+			name:         "pdf falls back to text",
+			capabilities: []string{"completion", "vision"},
+			mimeType:     "application/pdf",
+			content:      []byte("%PDF-1.4\n4 0 obj\n<< /Length 52 >>\nstream\nBT /F1 12 Tf 72 712 Td (Employment Contract) Tj ET\nendstream\nendobj\n"),
+			wantContent:  "text extracted from the file:\n\nEmployment Contract",
+		},
+		{
+			name:         "scanned pdf",
+			capabilities: []string{"completion"},
+			mimeType:     "application/pdf",
+			content:      []byte("%PDF-1.4\n4 0 obj\n<< /Subtype /Image >>\nstream\n\xff\xd8\xff\xe0 JFIF (not text)\nendstream\nendobj\n"),
+			wantContent:  "cannot read the content of this file",
+		},
+		{
+			name:         "text is sent inline",
+			capabilities: []string{"completion"},
+			mimeType:     "text/plain; charset=utf-8",
+			content:      []byte("Employment contract between A and B"),
+			wantContent:  "Employment contract between A and B",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			chats := make(chan ollamaChatRequest, 1)
+			var shows atomic.Int32
+			srv := newOllamaStandIn(t, tc.capabilities, chats, &shows)
+
+			p := NewOllamaProvider(strings.TrimPrefix(srv.URL, "http://"), "local-model")
+			suggestion, err := p.GenerateName(context.Background(), domain.NameRequest{
+				Content:  tc.content,
+				MimeType: tc.mimeType,
+				Name:     "photo.png",
+				Fields:   []string{"date", "subject"},
+			})
+			if err != nil {
+				t.Fatalf("GenerateName() error = %v", err)
+			}
+			if suggestion.Fields["subject"] != "Employment Contract" {
+				t.Errorf("GenerateName() = %+v", suggestion)
+			}
+
+			req := <-chats
+			if req.Model != "local-model" || req.Stream || req.Format["type"] != "object" {
+				t.Errorf("request = model %q, stream %v, format %v", req.Model, req.Stream, req.Format)
+			}
+			user := req.Messages[len(req.Messages)-1]
+			if got := len(user.Images) > 0; got != tc.wantImages {
+				t.Errorf("images sent = %v, want %v", got, tc.wantImages)
+			}
+			if !strings.Contains(user.Content, tc.wantContent) {
+				t.Errorf("content = %q, want it to contain %q", user.Content, tc.wantContent)
+			}
+		})
+	}
+}
+
+func TestOllamaProvider_CachesCapabilities(t *testing.T) {
+	t.Parallel()
+
+	chats := make(chan ollamaChatRequest, 2)
+	var shows atomic.Int32
+	srv := newOllamaStandIn(t, []string{"vision"}, chats, &shows)
+
+	p := NewOllamaProvider(srv.URL, "local-model")
+	for range 2 {
+		if _, err := p.GenerateName(context.Background(), domain.NameRequest{
+			Content:  []byte{0xff, 0xd8, 0xff},
+			MimeType: "image/jpeg",
+			Fields:   []string{"subject"},
+		}); err != nil {
+			t.Fatalf("GenerateName() error = %v", err)
+		}
+	}
+	if got := shows.Load(); got != 1 {
+		t.Errorf("/api/show called %d times, want 1", got)
+	}
+}
+
+func TestOllamaProvider_NoReadableContent(t *testing.T) {
+	t.Parallel()
+
+	chats := make(chan ollamaChatRequest, 1)
+	var shows atomic.Int32
+	srv := newOllamaStandIn(t, nil, chats, &shows)
+
+	p := NewOllamaProvider(srv.URL, "local-model")
+	if _, err := p.GenerateName(context.Background(), domain.NameRequest{
+		Content:  []byte{0x00, 0x01, 0x02},
+		MimeType: "video/mp4",
+		Name:     "holiday-2024.mp4",
+		Fields:   []string{"subject"},
+	}); err != nil {
+		t.Fatalf("GenerateName() error = %v", err)
+	}
+	user := (<-chats).Messages[1]
+	want := "Filename: holiday-2024.mp4\nType: video/mp4\nSize: 3 bytes"
+	if !strings.Contains(user.Content, want) || strings.Contains(user.Content, "\x00") || len(user.Images) > 0 {
+		t.Errorf("content = %q, want only the metadata %q", user.Content, want)
+	}
+}
+
+func TestPDFText(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write([]byte("q 1 0 0 1 0 0 cm BT /F1 11 Tf [(Employ) -20 (ment)] TJ (Contract) Tj ET Q"))
+	_ = zw.Close()
+
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		want    string
+	}{
+		{name: "plain stream", content: "stream\nBT (Invoice 42) Tj ET\nendstream", limit: 100, want: "Invoice 42"},
+		{name: "flate stream and TJ", content: "<< /Filter /FlateDecode >>\nstream\r\n" + compressed.String() + "\nendstream", limit: 100, want: "Employment Contract"},
+		{name: "escapes", content: "stream\nBT (Gr\\366\\337e \\(net\\)) Tj ET\nendstream", limit: 100, want: "Größe (net)"},
+		{name: "strings outside text ignored", content: "(Title) stream\n(not text) BT ET\nendstream", limit: 100, want: ""},
+		{name: "binary only", content: "stream\n\x00\x01\xff\nendstream", limit: 100, want: ""},
+		{name: "truncated", content: "stream\nBT (abcdefgh) Tj (ijklmnop) Tj ET\nendstream", limit: 10, want: "abcdefgh i"},
+		{name: "corrupt flate stream", content: "stream\n\x78\x9c\x01\x02garbage\nendstream", limit: 100, want: ""},
+		{name: "cut-off flate stream", content: "stream\n" + compressed.String()[:compressed.Len()/2] + "\nendstream", limit: 100, want: ""},
+		{name: "missing endstream", content: "stream\nBT (Invoice) Tj ET", limit: 100, want: ""},
+		{name: "unterminated string", content: "stream\nBT (Invoice\nendstream", limit: 100, want: "Invoice"},
+		{name: "unterminated text object", content: "stream\nBT [(Inv) (oice)\nendstream\nstream\nBT (Memo) Tj ET\nendstream", limit: 100, want: "Memo"},
+		{name: "unbalanced operators", content: "stream\nET ] ) (x) Tj BT (ok) Tj\nendstream", limit: 100, want: "ok"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := pdfText([]byte(tc.content), tc.limit); got != tc.want {
+				t.Errorf("pdfText() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInflate_Budget(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(bytes.Repeat([]byte("BT (x) Tj ET "), 100))
+	_ = zw.Close()
+
+	budget := 20
+	if got, ok := inflate(compressed.Bytes(), &budget); !ok || len(got) != 20 || budget != 0 {
+		t.Errorf("inflate() = %d bytes, %v, budget %d; want 20 bytes and the budget used up", len(got), ok, budget)
+	}
+	if _, ok := inflate(compressed.Bytes(), &budget); ok {
+		t.Error("inflate() with no budget left = ok, want it to give up")
+	}
+}
@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// DefaultOpenAIBaseURL is used when no base URL is configured.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider talks to any server implementing the OpenAI
+// /v1/chat/completions protocol with JSON-schema structured output.
+type OpenAIProvider struct {
+	http    httpClient
+	baseURL string
+	model   string
+}
+
+// NewOpenAIProvider creates a provider for the given base URL (including the
+// version path, e.g. "http://localhost:8080/v1"). The API key may be empty for
+// servers that do not require authentication.
+func NewOpenAIProvider(baseURL, apiKey, modelName string, opts ...HTTPOption) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAIProvider{
+		http:    newHTTPClient(apiKey, opts),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   modelName,
+	}
+}
+
+type chatRequest struct {
+	Model          string         `json:"model"`
+	Messages       []chatMessage  `json:"messages"`
+	ResponseFormat responseFormat `json:"response_format"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"` // string or []contentPart
+}
+
+type contentPart struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *imageURL   `json:"image_url,omitempty"`
+	File       *filePart   `json:"file,omitempty"`
+	InputAudio *inputAudio `json:"input_audio,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type filePart struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
+
+type inputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+type responseFormat struct {
+	Type       string     `json:"type"`
+	JSONSchema jsonSchema `json:"json_schema"`
+}
+
+type jsonSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) GenerateName(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	part, err := contentPartFor(req)
+	if err != nil {
+		return domain.NameSuggestion{}, err
+	}
+
+	in := chatRequest{
+		Model: p.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt(req, time.Now())},
+			{Role: "user", Content: []contentPart{
+				{Type: "text", Text: fileInstruction},
+				part,
+			}},
+		},
+		ResponseFormat: responseFormat{
+			Type: "json_schema",
+			JSONSchema: jsonSchema{
+				Name:   "filename_fields",
+				Strict: true,
+				Schema: strictSchema(responseSchema(req)),
+			},
+		},
+	}
+	for _, r := range req.History {
+		in.Messages = append(in.Messages,
+			chatMessage{Role: "assistant", Content: previousAnswer(r)},
+			chatMessage{Role: "user", Content: revisionPrompt(r)},
+		)
+	}
+	var chat chatResponse
+	if err := p.http.postJSON(ctx, p.baseURL+"/chat/completions", in, &chat); err != nil {
+		return domain.NameSuggestion{}, fmt.Errorf("failed to generate content: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return domain.NameSuggestion{}, fmt.Errorf("AI response contained no choices")
+	}
+	msg := chat.Choices[0].Message
+	if msg.Refusal != "" {
+		return domain.NameSuggestion{}, fmt.Errorf("AI refused the request: %s", msg.Refusal)
+	}
+
+	return parseAIResponse(msg.Content, req.Fields)
+}
+
+// contentPartFor encodes the file for the chat completions API: text inline,
+// images as data URLs, PDFs as file parts and WAV/MP3 as input audio.
+func contentPartFor(req domain.NameRequest) (contentPart, error) {
+	mimeType := strings.TrimSpace(strings.Split(req.MimeType, ";")[0])
+	encoded := base64.StdEncoding.EncodeToString(req.Content)
+
+	switch {
+	case domain.IsTextMimeType(mimeType):
+		return contentPart{Type: "text", Text: string(req.Content)}, nil
+	case strings.HasPrefix(mimeType, "image/"):
+		return contentPart{Type: "image_url", ImageURL: &imageURL{URL: "data:" + mimeType + ";base64," + encoded}}, nil
+	case mimeType == "application/pdf":
+		return contentPart{Type: "file", File: &filePart{Filename: "document" + req.Extension, FileData: "data:" + mimeType + ";base64," + encoded}}, nil
+	case mimeType == "audio/wav" || mimeType == "audio/x-wav":
+		return contentPart{Type: "input_audio", InputAudio: &inputAudio{Data: encoded, Format: "wav"}}, nil
+	case mimeType == "audio/mpeg" || mimeType == "audio/mp3":
+		return contentPart{Type: "input_audio", InputAudio: &inputAudio{Data: encoded, Format: "mp3"}}, nil
+	default:
+		return contentPart{}, fmt.Errorf("file type %s is not supported by the OpenAI-compatible provider", req.MimeType)
+	}
+}
+
+// strictSchema disallows additional properties on every object, as required by
+// strict structured outputs.
+func strictSchema(schema map[string]any) map[string]any {
+	switch schema["type"] {
+	case "object":
+		schema["additionalProperties"] = false
+		if props, ok := schema["properties"].(map[string]any); ok {
+			for _, prop := range props {
+				if m, ok := prop.(map[string]any); ok {
+					strictSchema(m)
+				}
+			}
+		}
+	case "array":
+		if items, ok := schema["items"].(map[string]any); ok {
+			strictSchema(items)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// newStandIn starts a local chat completions server that records the decoded
+// request and answers with the given status and body.
+func newStandIn(t *testing.T, status int, body string, got *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got != nil {
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			(*got)["_auth"] = r.Header.Get("Authorization")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func chatBody(content string) string {
+	b, _ := json.Marshal(map[string]any{
+		"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": content}}},
+	})
+	return string(b)
+}
+
+func TestOpenAIProvider_GenerateName(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := newStandIn(t, http.StatusOK, chatBody(`{"fields": {"date": "2024-03-01", "subject": "Beach Photo"}, "reasoning": "a beach"}`), &got)
+
+	p := NewOpenAIProvider(srv.URL+"/v1/", "secret", "test-model")
+	suggestion, err := p.GenerateName(context.Background(), domain.NameRequest{
+		Content:   []byte{0x89, 'P', 'N', 'G'},
+		MimeType:  "image/png",
+		Extension: ".png",
+		Template:  domain.DefaultTemplate,
+		Fields:    []string{"date", "subject"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateName() error = %v", err)
+	}
+	if suggestion.Fields["subject"] != "Beach Photo" || suggestion.Reasoning != "a beach" {
+		t.Errorf("GenerateName() = %+v", suggestion)
+	}
+
+	if got["_auth"] != "Bearer secret" {
+		t.Errorf("Authorization = %v, want Bearer secret", got["_auth"])
+	}
+	if got["model"] != "test-model" {
+		t.Errorf("model = %v, want test-model", got["model"])
+	}
+
+	format := got["response_format"].(map[string]any)
+	schema := format["json_schema"].(map[string]any)
+	if format["type"] != "json_schema" || schema["strict"] != true {
+		t.Errorf("response_format = %v", format)
+	}
+	if ap := schema["schema"].(map[string]any)["additionalProperties"]; ap != false {
+		t.Errorf("schema additionalProperties = %v, want false", ap)
+	}
+
+	messages := got["messages"].([]any)
+	if len(messages) != 2 || messages[0].(map[string]any)["role"] != "system" {
+		t.Fatalf("messages = %v", messages)
+	}
+	parts := messages[1].(map[string]any)["content"].([]any)
+	image := parts[1].(map[string]any)
+	if image["type"] != "image_url" {
+		t.Fatalf("part type = %v, want image_url", image["type"])
+	}
+	url := image["image_url"].(map[string]any)["url"].(string)
+	if !strings.HasPrefix(url, "data:image/png;base64,") {
+		t.Errorf("image url = %q, want a PNG data URL", url)
+	}
+}
+
+func TestOpenAIProvider_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		mimeType string
+		wantErr  string
+	}{
+		{
+			name:     "api error message",
+			status:   http.StatusUnauthorized,
+			body:     `{"error": {"message": "invalid api key"}}`,
+			mimeType: "text/plain",
+			wantErr:  "invalid api key",
+		},
+		{
+			name:     "refusal",
+			status:   http.StatusOK,
+			body:     `{"choices": [{"message": {"refusal": "cannot help"}}]}`,
+			mimeType: "text/plain",
+			wantErr:  "refused",
+		},
+		{
+			name:     "no choices",
+			status:   http.StatusOK,
+			body:     `{"choices": []}`,
+			mimeType: "text/plain",
+			wantErr:  "no choices",
+		},
+		{
+			name:     "missing field",
+			status:   http.StatusOK,
+			body:     chatBody(`{"fields": {"date": "2024-03-01"}, "reasoning": "x"}`),
+			mimeType: "text/plain",
+			wantErr:  `empty field "subject"`,
+		},
+		{
+			name:     "unsupported type",
+			status:   http.StatusOK,
+			body:     chatBody(`{}`),
+			mimeType: "video/mp4",
+			wantErr:  "not supported",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			srv := newStandIn(t, tc.status, tc.body, nil)
+			p := NewOpenAIProvider(srv.URL+"/v1", "", "test-model")
+			_, err := p.GenerateName(context.Background(), domain.NameRequest{
+				Content:  []byte("hello"),
+				MimeType: tc.mimeType,
+				Fields:   []string{"date", "subject"},
+			})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("GenerateName() error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_History(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := newStandIn(t, http.StatusOK, chatBody(`{"fields": {"subject": "ACME Invoice"}, "reasoning": "with client"}`), &got)
+
+	p := NewOpenAIProvider(srv.URL+"/v1", "", "test-model")
+	_, err := p.GenerateName(context.Background(), domain.NameRequest{
+		Content:  []byte("invoice for ACME"),
+		MimeType: "text/plain",
+		Fields:   []string{"subject"},
+		History: []domain.Revision{{
+			Suggestion: domain.NameSuggestion{Fields: map[string]string{"subject": "Invoice"}, Reasoning: "an invoice"},
+			Name:       "invoice.txt",
+			Hint:       "include the client name",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateName() error = %v", err)
+	}
+
+	messages := got["messages"].([]any)
+	if len(messages) != 4 {
+		t.Fatalf("got %d messages, want system, user, assistant, user", len(messages))
+	}
+	assistant := messages[2].(map[string]any)
+	if assistant["role"] != "assistant" || !strings.Contains(assistant["content"].(string), `"subject":"Invoice"`) {
+		t.Errorf("assistant message = %v", assistant)
+	}
+	hint := messages[3].(map[string]any)
+	if hint["role"] != "user" || !strings.Contains(hint["content"].(string), "include the client name") ||
+		!strings.Contains(hint["content"].(string), "invoice.txt") {
+		t.Errorf("hint message = %v", hint)
+	}
+}
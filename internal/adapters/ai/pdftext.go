@@ -0,0 +1,179 @@
+package ai
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxInflated caps how many bytes of compressed PDF streams are inflated in
+// total, so a small file cannot expand into an unbounded amount of memory.
+const maxInflated = 16 << 20
+
+// pdfEscapes are the single-character escapes of PDF literal strings. Any other
+// escaped character, including parentheses and the backslash, stands for itself.
+var pdfEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'b': '\b', 'f': '\f'}
+
+// pdfText extracts the text of a PDF on a best-effort basis, truncated to limit
+// bytes. It is not a PDF parser: it scans for stream ... endstream, inflates
+// Flate-compressed streams up to maxInflated bytes in total, and takes the
+// literal strings between BT and ET. That covers most digitally created PDFs.
+// Anything else, such as other filters, fonts with their own encoding,
+// malformed streams or scanned pages, yields no text rather than an error.
+func pdfText(content []byte, limit int) string {
+	var texts []string
+	size, budget := 0, maxInflated
+	for size < limit {
+		_, rest, ok := bytes.Cut(content, []byte("stream"))
+		if !ok {
+			break
+		}
+		data, rest, ok := bytes.Cut(bytes.TrimLeft(rest, "\r\n"), []byte("endstream"))
+		if !ok {
+			break
+		}
+		content = rest
+
+		if inflated, ok := inflate(data, &budget); ok {
+			data = inflated
+		}
+		for _, text := range textOperands(data) {
+			if text = strings.TrimSpace(printable(text)); text != "" {
+				texts = append(texts, text)
+				size += len(text) + 1
+			}
+		}
+	}
+
+	text := strings.Join(texts, " ")
+	if len(text) > limit {
+		text = strings.ToValidUTF8(text[:limit], "")
+	}
+	return text
+}
+
+// inflate decompresses a Flate stream, taking what it inflates from budget.
+// A stream that is cut off or corrupt keeps the part inflated before the error.
+func inflate(data []byte, budget *int) ([]byte, bool) {
+	if *budget <= 0 {
+		return nil, false
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	inflated, err := io.ReadAll(io.LimitReader(r, int64(*budget)))
+	*budget -= len(inflated)
+	return inflated, err == nil || len(inflated) > 0
+}
+
+// textOperands returns the literal strings inside the text objects (BT ... ET)
+// of a content stream. The pieces of a TJ array are joined into one string,
+// as they only differ in spacing.
+func textOperands(data []byte) []string {
+	var texts []string
+	var array strings.Builder
+	inText, inArray := false, false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '(':
+			s, n := literalString(data[i+1:])
+			i += n
+			switch {
+			case inArray:
+				array.WriteString(s)
+			case inText:
+				texts = append(texts, s)
+			}
+		case c == '[' && inText:
+			inArray = true
+			array.Reset()
+		case c == ']' && inArray:
+			inArray = false
+			texts = append(texts, array.String())
+		case isToken(data, i, "BT"):
+			inText = true
+		case isToken(data, i, "ET"):
+			inText, inArray = false, false
+		}
+	}
+	return texts
+}
+
+// isToken reports whether the operator tok starts at data[i] and stands alone.
+func isToken(data []byte, i int, tok string) bool {
+	if !bytes.HasPrefix(data[i:], []byte(tok)) {
+		return false
+	}
+	before := i == 0 || isPDFSpace(data[i-1])
+	after := i+len(tok) == len(data) || isPDFSpace(data[i+len(tok)])
+	return before && after
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// literalString decodes a PDF literal string whose opening parenthesis has
+// been consumed. It returns the string and how many bytes it spanned,
+// including the closing parenthesis; an unterminated string runs to the end.
+func literalString(data []byte) (string, int) {
+	var b []byte
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			i++
+			c = data[i]
+			if c < '0' || c > '7' {
+				if e, ok := pdfEscapes[c]; ok {
+					c = e
+				}
+				b = append(b, c)
+				continue
+			}
+			// Up to three octal digits.
+			v := c - '0'
+			for n := 1; n < 3 && i+1 < len(data) && data[i+1] >= '0' && data[i+1] <= '7'; n++ {
+				i++
+				v = v*8 + data[i] - '0'
+			}
+			b = append(b, v)
+		case c == ')' && depth == 0:
+			return string(b), i + 1
+		default:
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+			b = append(b, c)
+		}
+	}
+	return string(b), len(data)
+}
+
+// printable drops control characters and decodes strings that are not valid
+// UTF-8 as Latin-1, the basis of the standard PDF encodings.
+func printable(s string) string {
+	if !utf8.ValidString(s) {
+		runes := make([]rune, len(s))
+		for i := 0; i < len(s); i++ {
+			runes[i] = rune(s[i])
+		}
+		s = string(runes)
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsPrint(r) {
+			b.WriteRune(r)
+		} else if unicode.IsSpace(r) {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// PromptVersion must be increased whenever the prompt or response schema changes
+// in a way that makes previously cached suggestions stale.
+const PromptVersion = 2
+
+// fieldDescriptions explains well-known template fields to the model. Any other
+// field gets a generic description derived from its name.
+var fieldDescriptions = map[string]string{
+	domain.FieldDate: "The most relevant date of the content in ISO 8601 format (YYYY-MM-DD). If no specific date is found in the content, use the Current Date.",
+	"subject":        "The core subject or title of the content in a few words, e.g. 'Budget Report'.",
+	"title":          "The title of the content in a few words, e.g. 'Budget Report'.",
+	"category":       "A broad category for the content, e.g. 'Invoice', 'Contract', 'Receipt', 'Photo'.",
+	"author":         "The author, sender or issuing organization of the content.",
+	"recipient":      "The recipient or customer the content is addressed to.",
+}
+
+// describeField explains a field of the request to the model. When organizing,
+// the category is chosen from the taxonomy.
+func describeField(req domain.NameRequest, name string) string {
+	if name == domain.FieldCategory && len(req.Categories) > 0 {
+		return fmt.Sprintf("The category folder the content is filed in. Choose one of: %s. Only if none of them fits, propose a short new category.",
+			strings.Join(req.Categories, ", "))
+	}
+	return fieldDescription(name)
+}
+
+func fieldDescription(name string) string {
+	if d, ok := fieldDescriptions[name]; ok {
+		return d
+	}
+	return fmt.Sprintf("The %s of the content in a few words.", strings.ReplaceAll(name, "_", " "))
+}
+
+// systemPrompt builds the instructions shared by all providers.
+func systemPrompt(req domain.NameRequest, now time.Time) string {
+	var fields strings.Builder
+	for _, f := range req.Fields {
+		fmt.Fprintf(&fields, "		   - %s: %s\n", f, describeField(req, f))
+	}
+
+	prompt := fmt.Sprintf(`You are an intelligent file renaming assistant.
+		Context:
+		- Current Date: %s
+		- File extension: %s
+
+		Specific rules:
+		1. Analyze the attached content.
+		2. Summarize the content to identify its core subject and any relevant date.
+		3. Provide a value for each of the following fields. They are assembled into a filename with the layout %s:
+%s		4. Use plain words for every field. Casing, separators and the extension are applied automatically.
+		5. Example: for a budget report from December 2023, subject is "Budget Report" and date is "2023-12-01".
+		6. Rate your confidence from 0 to 1 that the values are correct and specific to this file.`,
+		now.Format("2006-01-02"), req.Extension, req.Template, fields.String())
+
+	if req.Candidates > 1 {
+		prompt += fmt.Sprintf("\n		7. Also provide %d alternative sets of values in \"alternatives\", ranked best first, each with its own reasoning and confidence.", req.Candidates-1)
+	}
+
+	if instructions := strings.TrimSpace(req.Instructions); instructions != "" {
+		prompt += "\n\n		Additional instructions:\n		" + instructions
+	}
+	return prompt
+}
+
+// fileInstruction is sent together with the file content.
+const fileInstruction = "Analyze the following file content and provide the filename fields."
+
+// previousAnswer renders an earlier suggestion the way the model returned it,
+// so that it can be replayed as the model's turn of the conversation.
+func previousAnswer(r domain.Revision) string {
+	data, _ := json.Marshal(aiResponse{aiCandidate: aiCandidate{
+		Fields:     r.Suggestion.Fields,
+		Reasoning:  r.Suggestion.Reasoning,
+		Confidence: r.Suggestion.Confidence,
+	}})
+	return string(data)
+}
+
+// revisionPrompt asks the model to improve its previous answer.
+func revisionPrompt(r domain.Revision) string {
+	return fmt.Sprintf("These fields produced the filename %q, which the user rejected. "+
+		"Provide new filename fields for the same file, following this hint: %s", r.Name, r.Hint)
+}
+
+// responseSchema describes the structured JSON output expected from the model.
+// With more than one candidate, the alternatives are part of the schema as well.
+func responseSchema(req domain.NameRequest) map[string]any {
+	schema := candidateSchema(req)
+	if req.Candidates > 1 {
+		props := schema["properties"].(map[string]any)
+		props["alternatives"] = map[string]any{
+			"type":        "array",
+			"description": fmt.Sprintf("%d alternative candidates, ranked best first.", req.Candidates-1),
+			"items":       candidateSchema(req),
+		}
+		schema["required"] = append(schema["required"].([]string), "alternatives")
+	}
+	return schema
+}
+
+// candidateSchema describes one set of field values.
+func candidateSchema(req domain.NameRequest) map[string]any {
+	fields := req.Fields
+	properties := make(map[string]any, len(fields))
+	for _, f := range fields {
+		properties[f] = map[string]any{
+			"type":        "string",
+			"description": describeField(req, f),
+		}
+	}
+
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"fields": map[string]any{
+				"type":       "object",
+				"properties": properties,
+				"required":   fields,
+			},
+			"reasoning": map[string]any{
+				"type":        "string",
+				"description": "Brief explanation of why these values were chosen.",
+			},
+			"confidence": map[string]any{
+				"type":        "number",
+				"description": "Confidence from 0 to 1 that the values are correct and specific.",
+			},
+		},
+		"required": []string{"fields", "reasoning", "confidence"},
+	}
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		req     domain.NameRequest
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "fields and layout",
+			req: domain.NameRequest{
+				Extension: ".pdf",
+				Template:  "{date}_{category}_{subject}{ext}",
+				Fields:    []string{"date", "category", "subject"},
+			},
+			want:    []string{"2024-03-24", ".pdf", "{date}_{category}_{subject}{ext}", "- category:", "- subject:"},
+			notWant: []string{"Additional instructions"},
+		},
+		{
+			name: "instructions appended",
+			req: domain.NameRequest{
+				Template:     domain.DefaultTemplate,
+				Fields:       []string{"date", "subject"},
+				Instructions: "Always include the vendor name.",
+			},
+			want: []string{"Additional instructions", "Always include the vendor name."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := systemPrompt(tc.req, now)
+			for _, w := range tc.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("prompt missing %q:\n%s", w, got)
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("prompt unexpectedly contains %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
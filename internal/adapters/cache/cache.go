@@ -0,0 +1,234 @@
+// Package cache stores AI suggestions on disk, keyed by file content and
+// everything else that influences the answer, so that re-running rnai on the
+// same file does not pay for the same request twice.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// DefaultTTL is how long a cached suggestion is used.
+const DefaultTTL = 30 * 24 * time.Hour
+
+// entry is the file stored for one cached suggestion.
+type entry struct {
+	Namespace string `json:"namespace"`
+	candidate
+	Alternatives []candidate `json:"alternatives,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// candidate is one set of field values of a cached suggestion.
+type candidate struct {
+	Fields     map[string]string `json:"fields"`
+	Reasoning  string            `json:"reasoning"`
+	Confidence float64           `json:"confidence"`
+}
+
+func newCandidate(s domain.NameSuggestion) candidate {
+	return candidate{Fields: s.Fields, Reasoning: s.Reasoning, Confidence: s.Confidence}
+}
+
+func (c candidate) suggestion() domain.NameSuggestion {
+	return domain.NameSuggestion{Fields: c.Fields, Reasoning: c.Reasoning, Confidence: c.Confidence}
+}
+
+// Cache is a directory with one JSON file per suggestion. Writes are atomic,
+// so concurrent workers and concurrent rnai processes can share it.
+type Cache struct {
+	dir       string
+	namespace string
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// New returns a cache in dir. The namespace identifies the provider, model and
+// prompt version; suggestions from another namespace are never returned. A ttl
+// of zero or less means entries never expire.
+func New(dir, namespace string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, namespace: namespace, ttl: ttl, now: time.Now}
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/rnai/suggestions, or the platform's user
+// cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "rnai", "suggestions"), nil
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key identifies a request: the content hash, the namespace and every part of
+// the request that ends up in the prompt.
+func (c *Cache) Key(req domain.NameRequest) string {
+	h := sha256.New()
+	for _, part := range []string{
+		c.namespace,
+		domain.ContentHash(req.Content),
+		req.MimeType,
+		strings.ToLower(req.Extension),
+		req.Template,
+		strings.Join(req.Fields, ","),
+		req.Instructions,
+		strconv.Itoa(max(req.Candidates, 1)),
+		strings.Join(req.Categories, ","),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the cached suggestion for req, if there is one that has not expired.
+func (c *Cache) Get(req domain.NameRequest) (domain.NameSuggestion, bool) {
+	data, err := os.ReadFile(c.path(c.Key(req)))
+	if err != nil {
+		return domain.NameSuggestion{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Namespace != c.namespace || c.expired(e) {
+		return domain.NameSuggestion{}, false
+	}
+	suggestion := e.suggestion()
+	for _, alt := range e.Alternatives {
+		suggestion.Alternatives = append(suggestion.Alternatives, alt.suggestion())
+	}
+	return suggestion, true
+}
+
+// Put stores the suggestion for req.
+func (c *Cache) Put(req domain.NameRequest, s domain.NameSuggestion) error {
+	e := entry{
+		Namespace: c.namespace,
+		candidate: newCandidate(s),
+		CreatedAt: c.now().UTC(),
+	}
+	for _, alt := range s.Alternatives {
+		e.Alternatives = append(e.Alternatives, newCandidate(alt))
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(c.Key(req))); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) expired(e entry) bool {
+	return c.ttl > 0 && c.now().Sub(e.CreatedAt) > c.ttl
+}
+
+// Stats describes the contents of the cache across all namespaces.
+type Stats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Stats scans the cache directory.
+func (c *Cache) Stats() (Stats, error) {
+	var st Stats
+	err := c.walk(func(path string, size int64, e entry, ok bool) error {
+		st.Entries++
+		st.Bytes += size
+		if !ok || c.expired(e) {
+			st.Expired++
+			return nil
+		}
+		if st.Oldest.IsZero() || e.CreatedAt.Before(st.Oldest) {
+			st.Oldest = e.CreatedAt
+		}
+		if e.CreatedAt.After(st.Newest) {
+			st.Newest = e.CreatedAt
+		}
+		return nil
+	})
+	return st, err
+}
+
+// Prune removes expired and unreadable entries, or every entry if all is set.
+// It returns the number of entries removed.
+func (c *Cache) Prune(all bool) (int, error) {
+	removed := 0
+	err := c.walk(func(path string, _ int64, e entry, ok bool) error {
+		if !all && ok && !c.expired(e) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// walk calls fn for every entry file; ok is false if the file cannot be decoded.
+func (c *Cache) walk(fn func(path string, size int64, e entry, ok bool) error) error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	for _, de := range dirEntries {
+		if de.IsDir() || filepath.Ext(de.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(c.dir, de.Name())
+		info, err := de.Info()
+		if err != nil {
+			continue // removed concurrently
+		}
+		var e entry
+		data, err := os.ReadFile(path)
+		ok := err == nil && json.Unmarshal(data, &e) == nil
+		if err := fn(path, info.Size(), e, ok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
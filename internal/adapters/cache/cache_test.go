@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestCache_GetPut(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := New(dir, "gemini/flash/prompt-v1", time.Hour)
+	req := domain.NameRequest{Content: []byte("invoice"), MimeType: "application/pdf", Extension: ".pdf", Fields: []string{"date", "subject"}}
+	want := domain.NameSuggestion{
+		Fields:       map[string]string{"subject": "Invoice"},
+		Reasoning:    "an invoice",
+		Confidence:   0.9,
+		Alternatives: []domain.NameSuggestion{{Fields: map[string]string{"subject": "Bill"}, Confidence: 0.4}},
+	}
+
+	if _, ok := c.Get(req); ok {
+		t.Fatal("Get() hit on an empty cache")
+	}
+	if err := c.Put(req, want); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, ok := c.Get(req)
+	if !ok || got.Fields["subject"] != "Invoice" || got.Reasoning != "an invoice" || got.Confidence != 0.9 {
+		t.Errorf("Get() = %+v, %v", got, ok)
+	}
+	if len(got.Alternatives) != 1 || got.Alternatives[0].Fields["subject"] != "Bill" || got.Alternatives[0].Confidence != 0.4 {
+		t.Errorf("Get() alternatives = %+v", got.Alternatives)
+	}
+
+	tests := []struct {
+		name  string
+		cache *Cache
+		req   domain.NameRequest
+	}{
+		{"other content", c, domain.NameRequest{Content: []byte("receipt"), MimeType: req.MimeType, Extension: req.Extension, Fields: req.Fields}},
+		{"other instructions", c, domain.NameRequest{Content: req.Content, MimeType: req.MimeType, Extension: req.Extension, Fields: req.Fields, Instructions: "German"}},
+		{"other candidates", c, domain.NameRequest{Content: req.Content, MimeType: req.MimeType, Extension: req.Extension, Fields: req.Fields, Candidates: 3}},
+		{"other categories", c, domain.NameRequest{Content: req.Content, MimeType: req.MimeType, Extension: req.Extension, Fields: req.Fields, Categories: []string{"Invoices"}}},
+		{"other namespace", New(dir, "openai/gpt/prompt-v1", time.Hour), req},
+	}
+	for _, tc := range tests {
+		if _, ok := tc.cache.Get(tc.req); ok {
+			t.Errorf("%s: Get() hit, want miss", tc.name)
+		}
+	}
+}
+
+func TestCache_ExpiryStatsAndPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 24, 10, 0, 0, 0, time.UTC)
+	c := New(t.TempDir(), "ns", time.Hour)
+	c.now = func() time.Time { return now }
+
+	old := domain.NameRequest{Content: []byte("old")}
+	fresh := domain.NameRequest{Content: []byte("fresh")}
+	if err := c.Put(old, domain.NameSuggestion{}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := c.Put(fresh, domain.NameSuggestion{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Get(old); ok {
+		t.Error("Get() returned an expired entry")
+	}
+	st, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if st.Entries != 2 || st.Expired != 1 || !st.Newest.Equal(now) {
+		t.Errorf("Stats() = %+v", st)
+	}
+
+	if removed, err := c.Prune(false); err != nil || removed != 1 {
+		t.Errorf("Prune(false) = %d, %v; want 1", removed, err)
+	}
+	if _, ok := c.Get(fresh); !ok {
+		t.Error("Prune(false) removed a fresh entry")
+	}
+	if removed, err := c.Prune(true); err != nil || removed != 1 {
+		t.Errorf("Prune(true) = %d, %v; want 1", removed, err)
+	}
+}
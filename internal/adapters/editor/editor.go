@@ -0,0 +1,63 @@
+// Package editor lets the user edit text in their preferred editor.
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Editor runs an external editor on a temporary file.
+type Editor struct {
+	command []string
+}
+
+// New returns an editor running $VISUAL or $EDITOR, falling back to vi. The
+// variable may include arguments, e.g. "code --wait".
+func New() *Editor {
+	cmd := os.Getenv("VISUAL")
+	if cmd == "" {
+		cmd = os.Getenv("EDITOR")
+	}
+	return NewWithCommand(cmd)
+}
+
+// NewWithCommand returns an editor running the given command line.
+func NewWithCommand(cmd string) *Editor {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	return &Editor{command: fields}
+}
+
+// Edit writes text to a temporary file, waits for the editor to exit and
+// returns the file's new content.
+func (e *Editor) Edit(text string) (string, error) {
+	f, err := os.CreateTemp("", "rnai-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := f.Name()
+	defer func() { _ = os.Remove(path) }()
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	cmd := exec.Command(e.command[0], append(e.command[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", e.command[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,23 @@
+package editor_test
+
+import (
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/editor"
+)
+
+func TestEditor_Edit(t *testing.T) {
+	t.Parallel()
+
+	got, err := editor.NewWithCommand("sed -i s/old/new/").Edit("a.pdf -> old.pdf\n")
+	if err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if got != "a.pdf -> new.pdf\n" {
+		t.Errorf("Edit() = %q", got)
+	}
+
+	if _, err := editor.NewWithCommand("false").Edit("text"); err == nil {
+		t.Error("Edit() with a failing editor returned no error")
+	}
+}
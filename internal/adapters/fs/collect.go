@@ -0,0 +1,141 @@
+package fs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CollectOptions controls how CollectFiles expands its input paths.
+type CollectOptions struct {
+	// Recursive descends into subdirectories when a directory is given.
+	Recursive bool
+	// Include, if non-empty, keeps only files whose name matches at least one glob.
+	Include []string
+	// Exclude drops files whose name matches any glob.
+	Exclude []string
+}
+
+// CollectFiles expands the given paths into a sorted, de-duplicated list of regular files.
+// Paths may be files, directories or glob patterns. Hidden files and directories are
+// skipped when walking a directory, but are kept when named explicitly.
+func (fs *OsFileSystem) CollectFiles(paths []string, opts CollectOptions) ([]string, error) {
+	if err := validatePatterns(opts.Include); err != nil {
+		return nil, err
+	}
+	if err := validatePatterns(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	var files []string
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if _, ok := seen[path]; ok || !opts.matches(path) {
+			return
+		}
+		seen[path] = struct{}{}
+		files = append(files, path)
+	}
+
+	for _, arg := range paths {
+		matches, err := expandGlob(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("file not found: %s", path)
+			}
+			if !info.IsDir() {
+				if info.Mode().IsRegular() {
+					add(path)
+				}
+				continue
+			}
+			if err := walkDir(path, opts.Recursive, add); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// expandGlob returns the paths matching arg if it contains glob metacharacters,
+// or arg itself otherwise.
+func expandGlob(arg string) ([]string, error) {
+	if !strings.ContainsAny(arg, "*?[") {
+		return []string{arg}, nil
+	}
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match pattern: %s", arg)
+	}
+	return matches, nil
+}
+
+func walkDir(root string, recursive bool, add func(string)) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", root, err)
+	}
+	return nil
+}
+
+func (o CollectOptions) matches(path string) bool {
+	name := filepath.Base(path)
+	if len(o.Include) > 0 && !matchAny(o.Include, name) {
+		return false
+	}
+	return !matchAny(o.Exclude, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/fs"
+)
+
+func TestOsFileSystem_CollectFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{
+		"a.pdf",
+		"b.txt",
+		".hidden.pdf",
+		"sub/c.pdf",
+		"sub/deep/d.pdf",
+		".git/config",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(names ...string) []string {
+		out := make([]string, len(names))
+		for i, n := range names {
+			out[i] = filepath.Join(root, n)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		opts    fs.CollectOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "single file",
+			paths: join("a.pdf"),
+			want:  join("a.pdf"),
+		},
+		{
+			name:  "directory skips hidden and subdirectories",
+			paths: []string{root},
+			want:  join("a.pdf", "b.txt"),
+		},
+		{
+			name:  "recursive directory",
+			paths: []string{root},
+			opts:  fs.CollectOptions{Recursive: true},
+			want:  join("a.pdf", "b.txt", "sub/c.pdf", "sub/deep/d.pdf"),
+		},
+		{
+			name:  "explicit hidden file is kept",
+			paths: join(".hidden.pdf"),
+			want:  join(".hidden.pdf"),
+		},
+		{
+			name:  "glob pattern",
+			paths: []string{filepath.Join(root, "*.pdf")},
+			want:  join(".hidden.pdf", "a.pdf"),
+		},
+		{
+			name:  "include filter",
+			paths: []string{root},
+			opts:  fs.CollectOptions{Recursive: true, Include: []string{"*.pdf"}},
+			want:  join("a.pdf", "sub/c.pdf", "sub/deep/d.pdf"),
+		},
+		{
+			name:  "exclude filter",
+			paths: []string{root},
+			opts:  fs.CollectOptions{Recursive: true, Exclude: []string{"c.*", "*.txt"}},
+			want:  join("a.pdf", "sub/deep/d.pdf"),
+		},
+		{
+			name:  "duplicates removed",
+			paths: append(join("a.pdf", "a.pdf"), root),
+			want:  join("a.pdf", "b.txt"),
+		},
+		{
+			name:    "missing file",
+			paths:   join("missing.pdf"),
+			wantErr: true,
+		},
+		{
+			name:    "glob without matches",
+			paths:   []string{filepath.Join(root, "*.docx")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid include pattern",
+			paths:   []string{root},
+			opts:    fs.CollectOptions{Include: []string{"["}},
+			wantErr: true,
+		},
+	}
+
+	fileSys := fs.NewOsFileSystem()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := fileSys.CollectFiles(tc.paths, tc.opts)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CollectFiles() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("CollectFiles() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
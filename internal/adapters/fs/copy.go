@@ -0,0 +1,151 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ProgressFunc is told how many of total bytes of path have been copied.
+type ProgressFunc func(path string, copied, total int64)
+
+// progressThreshold is the size from which copies report progress.
+const progressThreshold = 32 << 20
+
+// copyContent copies the content of a file. Tests replace it to interrupt a
+// copy.
+var copyContent = io.Copy
+
+// moveAcrossDevices moves a file to another filesystem, where it cannot be
+// renamed: it copies the content, mode, timestamps and extended attributes
+// into a temporary file next to newPath, flushes the copy to disk and compares
+// its checksum with the source. Only then is the copy renamed to newPath, so
+// an interrupted copy never leaves a truncated file under the new name. An
+// existing newPath is never replaced, unless replace is set. On any error
+// before the copy is in place it is removed and the source is left untouched.
+func moveAcrossDevices(oldPath, newPath string, replace bool, progress ProgressFunc) (err error) {
+	src, err := os.Open(oldPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot move %s across filesystems: not a regular file", oldPath)
+	}
+	// Checked before copying so that a taken name does not cost a full copy;
+	// renameNoReplace below checks again.
+	if _, err := os.Lstat(newPath); err == nil && !replace {
+		return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: os.ErrExist}
+	}
+
+	dst, err := os.CreateTemp(filepath.Dir(newPath), "."+filepath.Base(newPath)+".*")
+	if err != nil {
+		return err
+	}
+	copyPath := dst.Name()
+	placed := false
+	defer func() {
+		if !placed {
+			_ = dst.Close()
+			_ = os.Remove(copyPath)
+		}
+	}()
+
+	h := sha256.New()
+	w := io.Writer(dst)
+	if progress != nil && info.Size() >= progressThreshold {
+		w = &progressWriter{w: dst, path: oldPath, total: info.Size(), report: progress}
+	}
+	if _, err := copyContent(w, io.TeeReader(src, h)); err != nil {
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+	if err := copyMetadata(src, dst, info); err != nil {
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		return fmt.Errorf("failed to flush copy: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	// Timestamps are set last: closing a written file may update them.
+	if err := os.Chtimes(copyPath, accessTime(info), info.ModTime()); err != nil {
+		return fmt.Errorf("failed to preserve timestamps: %w", err)
+	}
+
+	if after, err := src.Stat(); err != nil || after.Size() != info.Size() || !after.ModTime().Equal(info.ModTime()) {
+		return fmt.Errorf("%s changed while it was copied", oldPath)
+	}
+	sum, err := hashFile(copyPath)
+	if err != nil {
+		return err
+	}
+	if want := hex.EncodeToString(h.Sum(nil)); sum != want {
+		return fmt.Errorf("copy of %s is corrupt: checksum %s, want %s", oldPath, sum, want)
+	}
+	if replace {
+		err = os.Rename(copyPath, newPath)
+	} else {
+		err = renameNoReplace(copyPath, newPath)
+	}
+	if err != nil {
+		return err
+	}
+	placed = true
+	syncDir(filepath.Dir(newPath))
+
+	if err := os.Remove(oldPath); err != nil {
+		return fmt.Errorf("copied to %s but could not remove the original, so both files now exist: %w", newPath, err)
+	}
+	return nil
+}
+
+// copyMetadata gives dst the permission bits and extended attributes of src.
+// The mode passed to OpenFile is reduced by the umask, so it is set again.
+func copyMetadata(src, dst *os.File, info os.FileInfo) error {
+	if err := dst.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to preserve mode: %w", err)
+	}
+	if err := copyXattrs(src, dst); err != nil {
+		return fmt.Errorf("failed to preserve extended attributes: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes the directory entry of a new file. Not every platform and
+// filesystem supports it, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// progressWriter reports every whole percent of a copy.
+type progressWriter struct {
+	w       io.Writer
+	path    string
+	copied  int64
+	total   int64
+	percent int64
+	report  ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.copied += int64(n)
+	if percent := p.copied * 100 / p.total; percent > p.percent || p.copied == p.total {
+		p.percent = percent
+		p.report(p.path, p.copied, p.total)
+	}
+	return n, err
+}
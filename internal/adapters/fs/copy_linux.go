@@ -0,0 +1,84 @@
+//go:build linux
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// accessTime returns when the file of info was last read.
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Unix())
+	}
+	return time.Time{}
+}
+
+// copyXattrs copies the extended attributes of src to dst. Attributes the
+// destination filesystem or the user may not set, such as those in the
+// security and trusted namespaces, are skipped.
+func copyXattrs(src, dst *os.File) error {
+	names, err := xattrNames(int(src.Fd()))
+	if err != nil || len(names) == 0 {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	for _, name := range names {
+		value, err := xattr(int(src.Fd()), name)
+		if err != nil {
+			return err
+		}
+		err = unix.Fsetxattr(int(dst.Fd()), name, value, 0)
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) {
+			continue
+		}
+		if err != nil {
+			return &os.PathError{Op: "setxattr", Path: dst.Name(), Err: err}
+		}
+	}
+	return nil
+}
+
+func xattrNames(fd int) ([]string, error) {
+	for {
+		size, err := unix.Flistxattr(fd, nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Flistxattr(fd, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // attributes were added in between
+		}
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(strings.TrimSuffix(string(buf[:n]), "\x00"), "\x00"), nil
+	}
+}
+
+func xattr(fd int, name string) ([]byte, error) {
+	for {
+		size, err := unix.Fgetxattr(fd, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Fgetxattr(fd, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns the zero time, which leaves the access time of a copy
+// unchanged, where it cannot be read portably.
+func accessTime(os.FileInfo) time.Time {
+	return time.Time{}
+}
+
+// copyXattrs does nothing: extended attributes are only copied on Linux.
+func copyXattrs(_, _ *os.File) error {
+	return nil
+}
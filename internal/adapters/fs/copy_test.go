@@ -0,0 +1,167 @@
+//go:build linux
+
+package fs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestMoveAcrossDevices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "scan.pdf"), filepath.Join(dir, "invoice.pdf")
+	writeFile(t, src, "content")
+	if err := os.Chmod(src, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 3, 24, 10, 15, 0, 0, time.UTC)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	xattrs := unix.Setxattr(src, "user.origin", []byte("scanner"), 0) == nil
+
+	if err := moveAcrossDevices(src, dst, false, nil); err != nil {
+		t.Fatalf("moveAcrossDevices() error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "content" {
+		t.Errorf("content = %q", data)
+	}
+	if info.Mode().Perm() != 0o640 || !info.ModTime().Equal(mtime) {
+		t.Errorf("mode %v, mtime %v; want 0640, %v", info.Mode().Perm(), info.ModTime(), mtime)
+	}
+	if xattrs {
+		buf := make([]byte, 64)
+		n, err := unix.Getxattr(dst, "user.origin", buf)
+		if err != nil || string(buf[:n]) != "scanner" {
+			t.Errorf("xattr user.origin = %q, %v; want scanner", buf[:n], err)
+		}
+	}
+}
+
+func TestMoveAcrossDevices_NeverReplaces(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "scan.pdf"), filepath.Join(dir, "invoice.pdf")
+	writeFile(t, src, "new")
+	writeFile(t, dst, "existing")
+
+	if err := moveAcrossDevices(src, dst, false, nil); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("moveAcrossDevices() error = %v, want os.ErrExist", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "existing" {
+		t.Errorf("target content = %q, want it untouched", data)
+	}
+	if data, _ := os.ReadFile(src); string(data) != "new" {
+		t.Errorf("source content = %q, want it untouched", data)
+	}
+}
+
+func TestMoveAcrossDevices_Replace(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "scan.pdf"), filepath.Join(dir, "invoice.pdf")
+	writeFile(t, src, "new")
+	writeFile(t, dst, "existing")
+
+	if err := moveAcrossDevices(src, dst, true, nil); err != nil {
+		t.Fatalf("moveAcrossDevices() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "new" {
+		t.Errorf("target content = %q, want it replaced", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the target", len(entries))
+	}
+}
+
+// TestMoveAcrossDevices_Interrupted fails the copy halfway, as a full disk
+// would. It replaces copyContent, so it must not run in parallel.
+func TestMoveAcrossDevices_Interrupted(t *testing.T) {
+	copyContent = func(dst io.Writer, src io.Reader) (int64, error) {
+		n, _ := io.CopyN(dst, src, 4)
+		return n, errors.New("no space left on device")
+	}
+	t.Cleanup(func() { copyContent = io.Copy })
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "scan.pdf"), filepath.Join(dir, "invoice.pdf")
+	writeFile(t, src, "content")
+
+	if err := moveAcrossDevices(src, dst, false, nil); err == nil {
+		t.Fatal("moveAcrossDevices() error = nil, want the copy error")
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Errorf("target exists after an interrupted copy: %v", err)
+	}
+	if data, _ := os.ReadFile(src); string(data) != "content" {
+		t.Errorf("source content = %q, want it untouched", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the source", len(entries))
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	t.Parallel()
+
+	var reports []int64
+	w := &progressWriter{w: nopWriter{}, path: "scan.pdf", total: 1000, report: func(path string, copied, total int64) {
+		reports = append(reports, copied)
+	}}
+	for range 200 {
+		_, _ = w.Write(make([]byte, 5))
+	}
+	if len(reports) != 100 || reports[0] != 10 || reports[99] != 1000 {
+		t.Errorf("reported %d times, first %d, last %d; want every percent up to 1000", len(reports), reports[0], reports[len(reports)-1])
+	}
+}
+
+// TestRename_CrossDevice moves a file between the temporary directory and
+// /dev/shm, if they are different filesystems.
+func TestRename_CrossDevice(t *testing.T) {
+	t.Parallel()
+
+	other, err := os.MkdirTemp("/dev/shm", "rnai-test-")
+	if err != nil {
+		t.Skip("no /dev/shm")
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(other) })
+
+	src := filepath.Join(t.TempDir(), "scan.pdf")
+	writeFile(t, src, "content")
+	if err := os.Link(src, filepath.Join(other, "probe")); !errors.Is(err, unix.EXDEV) {
+		t.Skip("temporary directory and /dev/shm are on the same filesystem")
+	}
+
+	dst := filepath.Join(other, "invoice.pdf")
+	if err := NewOsFileSystem().Rename(src, dst); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "content" {
+		t.Errorf("content = %q", data)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(b []byte) (int, error) { return len(b), nil }
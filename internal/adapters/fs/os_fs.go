@@ -1,18 +1,32 @@
 package fs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"syscall"
+	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 )
 
-type OsFileSystem struct{}
+type OsFileSystem struct {
+	progress ProgressFunc
+}
 
 func NewOsFileSystem() *OsFileSystem {
 	return &OsFileSystem{}
 }
 
+// SetProgress reports the progress of large files that Rename has to copy to
+// another filesystem to fn.
+func (fs *OsFileSystem) SetProgress(fn ProgressFunc) {
+	fs.progress = fn
+}
+
 func (fs *OsFileSystem) ReadFile(path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -21,18 +35,47 @@ func (fs *OsFileSystem) ReadFile(path string) ([]byte, error) {
 	return data, nil
 }
 
+// Rename moves oldPath to newPath without ever replacing an existing file. If
+// newPath exists, even if it was created an instant earlier by another
+// process, it fails with an error matching os.ErrExist. A file is moved to
+// another filesystem by copying and verifying it before the original is
+// removed.
 func (fs *OsFileSystem) Rename(oldPath, newPath string) error {
-	if err := os.Rename(oldPath, newPath); err != nil {
+	err := renameNoReplace(oldPath, newPath)
+	if errors.Is(err, syscall.EXDEV) {
+		err = moveAcrossDevices(oldPath, newPath, false, fs.progress)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
 	}
 	return nil
 }
 
+// Replace moves oldPath to newPath like Rename, but replaces newPath if it
+// exists.
+func (fs *OsFileSystem) Replace(oldPath, newPath string) error {
+	err := os.Rename(oldPath, newPath)
+	if errors.Is(err, syscall.EXDEV) {
+		err = moveAcrossDevices(oldPath, newPath, true, fs.progress)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to replace %s with %s: %w", newPath, oldPath, err)
+	}
+	return nil
+}
+
 func (fs *OsFileSystem) Exists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
 }
 
+func (fs *OsFileSystem) MkdirAll(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	return nil
+}
+
 func (fs *OsFileSystem) GetMimeType(path string) (string, error) {
 	mtype, err := mimetype.DetectFile(path)
 	if err != nil {
@@ -40,3 +83,32 @@ func (fs *OsFileSystem) GetMimeType(path string) (string, error) {
 	}
 	return mtype.String(), nil
 }
+
+// ModTime returns the modification time of path.
+func (fs *OsFileSystem) ModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return info.ModTime(), nil
+}
+
+// HashFile streams the file through SHA-256. The result matches domain.ContentHash
+// of the file's content.
+func (fs *OsFileSystem) HashFile(path string) (string, error) {
+	return hashFile(path)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+// linkRename renames by hard-linking newPath to oldPath and then removing
+// oldPath. Creating the link fails if newPath exists, so, unlike os.Rename, it
+// never replaces a file. Filesystems without hard links fall back to checking
+// for newPath right before os.Rename, which leaves a short window for a race.
+func linkRename(oldPath, newPath string) error {
+	err := os.Link(oldPath, newPath)
+	switch {
+	case err == nil:
+		if err := os.Remove(oldPath); err != nil {
+			_ = os.Remove(newPath)
+			return err
+		}
+		return nil
+	case errors.Is(err, os.ErrExist):
+		return sameFileRename(oldPath, newPath, err)
+	case isUnsupported(err):
+		if _, err := os.Lstat(newPath); err == nil {
+			return sameFileRename(oldPath, newPath, &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: os.ErrExist})
+		}
+		return os.Rename(oldPath, newPath)
+	default:
+		return err
+	}
+}
+
+// sameFileRename handles a target that exists because it is the source itself,
+// as when only the case of a name changes on a case-insensitive filesystem.
+// Such a rename is safe; any other existing target fails with exists.
+func sameFileRename(oldPath, newPath string, exists error) error {
+	oldInfo, err := os.Lstat(oldPath)
+	if err != nil {
+		return exists
+	}
+	newInfo, err := os.Lstat(newPath)
+	if err != nil || !os.SameFile(oldInfo, newInfo) {
+		return exists
+	}
+	return os.Rename(oldPath, newPath)
+}
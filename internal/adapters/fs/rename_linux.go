@@ -0,0 +1,31 @@
+//go:build linux
+
+package fs
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// renameNoReplace renames atomically with renameat2(RENAME_NOREPLACE), which
+// fails with EEXIST if newPath exists. Kernels and filesystems without it fall
+// back to linkRename.
+func renameNoReplace(oldPath, newPath string) error {
+	err := unix.Renameat2(unix.AT_FDCWD, oldPath, unix.AT_FDCWD, newPath, unix.RENAME_NOREPLACE)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, unix.ENOSYS), errors.Is(err, unix.EINVAL):
+		return linkRename(oldPath, newPath)
+	case errors.Is(err, unix.EEXIST):
+		return sameFileRename(oldPath, newPath, &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: err})
+	default:
+		return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: err}
+	}
+}
+
+func isUnsupported(err error) bool {
+	return errors.Is(err, unix.EPERM) || errors.Is(err, unix.EOPNOTSUPP)
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package fs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// renameNoReplace renames without replacing an existing newPath. Only Linux
+// has an atomic system call for it; elsewhere it links and unlinks.
+func renameNoReplace(oldPath, newPath string) error {
+	return linkRename(oldPath, newPath)
+}
+
+func isUnsupported(err error) bool {
+	return errors.Is(err, errors.ErrUnsupported) || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+var renamers = map[string]func(oldPath, newPath string) error{
+	"renameNoReplace": renameNoReplace,
+	"linkRename":      linkRename,
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRename_NeverReplaces(t *testing.T) {
+	t.Parallel()
+
+	for name, rename := range renamers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			src, dst := filepath.Join(dir, "scan.pdf"), filepath.Join(dir, "invoice.pdf")
+			writeFile(t, src, "new")
+			writeFile(t, dst, "existing")
+
+			if err := rename(src, dst); !errors.Is(err, os.ErrExist) {
+				t.Fatalf("rename() error = %v, want os.ErrExist", err)
+			}
+			if data, _ := os.ReadFile(dst); string(data) != "existing" {
+				t.Errorf("target content = %q, want it untouched", data)
+			}
+			if _, err := os.Stat(src); err != nil {
+				t.Errorf("source is gone: %v", err)
+			}
+
+			free := filepath.Join(dir, "invoice-1.pdf")
+			if err := rename(src, free); err != nil {
+				t.Fatalf("rename() to a free name error = %v", err)
+			}
+			if data, _ := os.ReadFile(free); string(data) != "new" {
+				t.Errorf("renamed content = %q, want %q", data, "new")
+			}
+			if _, err := os.Stat(src); !os.IsNotExist(err) {
+				t.Errorf("source still exists after rename: %v", err)
+			}
+		})
+	}
+}
+
+// TestRename_Race renames many files to the same name at once, as concurrent
+// runs that resolved their collisions at the same moment would. Exactly one
+// may win; every other file must keep its name and content.
+func TestRename_Race(t *testing.T) {
+	t.Parallel()
+
+	for name, rename := range renamers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			dst := filepath.Join(dir, "invoice.pdf")
+			const n = 16
+			srcs := make([]string, n)
+			for i := range srcs {
+				srcs[i] = filepath.Join(dir, "scan"+string(rune('a'+i))+".pdf")
+				writeFile(t, srcs[i], srcs[i])
+			}
+
+			errs := make([]error, n)
+			var wg sync.WaitGroup
+			for i := range srcs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = rename(srcs[i], dst)
+				}()
+			}
+			wg.Wait()
+
+			winner := -1
+			for i, err := range errs {
+				switch {
+				case err == nil && winner >= 0:
+					t.Fatalf("both %s and %s were renamed to the same target", srcs[winner], srcs[i])
+				case err == nil:
+					winner = i
+				case !errors.Is(err, os.ErrExist):
+					t.Errorf("rename(%s) error = %v, want os.ErrExist", srcs[i], err)
+				}
+			}
+			if winner < 0 {
+				t.Fatal("no rename succeeded")
+			}
+			if data, _ := os.ReadFile(dst); string(data) != srcs[winner] {
+				t.Errorf("target content = %q, want the winner's %q", data, srcs[winner])
+			}
+			for i, src := range srcs {
+				if i == winner {
+					continue
+				}
+				if data, err := os.ReadFile(src); err != nil || string(data) != src {
+					t.Errorf("%s was lost or changed: %q, %v", src, data, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Package journal persists applied renames so that they can be undone later.
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// Actions recorded in the journal.
+const (
+	ActionRename = "rename"
+	ActionUndo   = "undo"
+)
+
+// Reasons an undo is refused.
+var (
+	ErrTargetMissing = errors.New("renamed file no longer exists")
+	ErrTargetChanged = errors.New("renamed file has changed since it was renamed")
+	ErrOriginalTaken = errors.New("original name is now taken")
+)
+
+// FileSystem is the subset of filesystem operations needed to reverse a rename.
+type FileSystem interface {
+	Exists(path string) bool
+	HashFile(path string) (string, error)
+	Rename(oldPath, newPath string) error
+}
+
+// Entry is a single line of the journal. Paths are absolute.
+type Entry struct {
+	Action       string    `json:"action"`
+	RunID        string    `json:"run_id"`
+	OriginalPath string    `json:"original_path"`
+	NewPath      string    `json:"new_path"`
+	ContentHash  string    `json:"content_hash"`
+	Model        string    `json:"model,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Journal is an append-only JSON Lines log of renames.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+}
+
+func New(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// DefaultPath returns the journal location: $XDG_STATE_HOME/rnai/journal.jsonl,
+// falling back to ~/.local/state on Linux and the user config directory elsewhere.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "rnai", "journal.jsonl"), nil
+	}
+	if runtime.GOOS == "linux" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate home directory: %w", err)
+		}
+		return filepath.Join(home, ".local", "state", "rnai", "journal.jsonl"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "rnai", "journal.jsonl"), nil
+}
+
+// NewRunID returns a sortable, unique identifier for one invocation of rnai.
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// Path returns the location of the journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append writes the entry to the journal and syncs it to disk.
+func (j *Journal) Append(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal %s: %w", j.path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write journal %s: %w", j.path, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync journal %s: %w", j.path, err)
+	}
+	return f.Close()
+}
+
+// Entries reads all entries in the order they were written. A missing journal
+// yields no entries.
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal %s: %w", j.path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupt journal %s at line %d: %w", j.path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal %s: %w", j.path, err)
+	}
+	return entries, nil
+}
+
+// Pending returns the rename entries that have not been undone yet, oldest first.
+func Pending(entries []Entry) []Entry {
+	var pending []Entry
+	for _, e := range entries {
+		switch e.Action {
+		case ActionRename:
+			pending = append(pending, e)
+		case ActionUndo:
+			// An undo reverses the most recent matching rename of the same run.
+			for i := len(pending) - 1; i >= 0; i-- {
+				p := pending[i]
+				if p.RunID == e.RunID && p.OriginalPath == e.OriginalPath && p.NewPath == e.NewPath {
+					pending = append(pending[:i], pending[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	return pending
+}
+
+// ByRun returns the pending renames of the given run.
+func ByRun(pending []Entry, runID string) []Entry {
+	var out []Entry
+	for _, e := range pending {
+		if e.RunID == runID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Last returns the pending renames of the most recent run that still has any.
+func Last(pending []Entry) []Entry {
+	if len(pending) == 0 {
+		return nil
+	}
+	return ByRun(pending, pending[len(pending)-1].RunID)
+}
+
+// ByPath returns the most recent pending rename whose new path is path.
+func ByPath(pending []Entry, path string) []Entry {
+	for i := len(pending) - 1; i >= 0; i-- {
+		if pending[i].NewPath == path {
+			return []Entry{pending[i]}
+		}
+	}
+	return nil
+}
+
+// Undo reverses a rename entry and records the reversal. It refuses when the
+// renamed file is gone or its content changed, or when the original name has
+// been taken by another file in the meantime.
+func (j *Journal) Undo(fsys FileSystem, e Entry) error {
+	if !fsys.Exists(e.NewPath) {
+		return fmt.Errorf("%s: %w", e.NewPath, ErrTargetMissing)
+	}
+	hash, err := fsys.HashFile(e.NewPath)
+	if err != nil {
+		return err
+	}
+	if hash != e.ContentHash {
+		return fmt.Errorf("%s: %w", e.NewPath, ErrTargetChanged)
+	}
+	if fsys.Exists(e.OriginalPath) {
+		return fmt.Errorf("%s: %w", e.OriginalPath, ErrOriginalTaken)
+	}
+
+	if err := fsys.Rename(e.NewPath, e.OriginalPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s: %w", e.OriginalPath, ErrOriginalTaken)
+		}
+		return err
+	}
+
+	undo := e
+	undo.Action = ActionUndo
+	undo.Timestamp = time.Time{}
+	if err := j.Append(undo); err != nil {
+		return fmt.Errorf("renamed back but failed to record undo: %w", err)
+	}
+	return nil
+}
+
+// Recorder records the renames of one run. It implements ports.Journal.
+type Recorder struct {
+	journal *Journal
+	runID   string
+	model   string
+}
+
+// Recorder returns a Recorder that writes entries for the given run and model.
+func (j *Journal) Recorder(runID, model string) *Recorder {
+	return &Recorder{journal: j, runID: runID, model: model}
+}
+
+// Record appends a rename entry for an applied result.
+func (r *Recorder) Record(result domain.RenameResult) error {
+	oldPath, err := filepath.Abs(result.OriginalPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", result.OriginalPath, err)
+	}
+	newPath, err := filepath.Abs(result.NewPath())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", result.NewPath(), err)
+	}
+	return r.journal.Append(Entry{
+		Action:       ActionRename,
+		RunID:        r.runID,
+		OriginalPath: oldPath,
+		NewPath:      newPath,
+		ContentHash:  result.ContentHash,
+		Model:        r.model,
+	})
+}
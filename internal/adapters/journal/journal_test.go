@@ -0,0 +1,116 @@
+package journal_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/fs"
+	"github.com/maltehedderich/rename-ai/internal/adapters/journal"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestPendingAndSelectors(t *testing.T) {
+	t.Parallel()
+
+	entries := []journal.Entry{
+		{Action: journal.ActionRename, RunID: "run-1", OriginalPath: "/a", NewPath: "/b"},
+		{Action: journal.ActionRename, RunID: "run-1", OriginalPath: "/c", NewPath: "/d"},
+		{Action: journal.ActionRename, RunID: "run-2", OriginalPath: "/e", NewPath: "/f"},
+		{Action: journal.ActionUndo, RunID: "run-2", OriginalPath: "/e", NewPath: "/f"},
+		{Action: journal.ActionUndo, RunID: "run-1", OriginalPath: "/c", NewPath: "/d"},
+	}
+
+	pending := journal.Pending(entries)
+	if len(pending) != 1 || pending[0].NewPath != "/b" {
+		t.Fatalf("Pending() = %+v, want only /a -> /b", pending)
+	}
+
+	tests := []struct {
+		name string
+		got  []journal.Entry
+		want int
+	}{
+		{"last skips fully undone runs", journal.Last(pending), 1},
+		{"by run", journal.ByRun(journal.Pending(entries[:3]), "run-1"), 2},
+		{"by run unknown", journal.ByRun(pending, "run-9"), 0},
+		{"by path", journal.ByPath(pending, "/b"), 1},
+		{"by path already undone", journal.ByPath(pending, "/f"), 0},
+	}
+	for _, tc := range tests {
+		if len(tc.got) != tc.want {
+			t.Errorf("%s: got %d entries, want %d", tc.name, len(tc.got), tc.want)
+		}
+	}
+}
+
+func TestJournal_AppendAndUndo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	j := journal.New(filepath.Join(dir, "state", "journal.jsonl"))
+	fileSys := fs.NewOsFileSystem()
+
+	content := []byte("invoice")
+	original := filepath.Join(dir, "scan.pdf")
+	renamed := filepath.Join(dir, "2024-01-01_invoice.pdf")
+	write := func(path string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(renamed, content)
+
+	entry := journal.Entry{
+		Action:       journal.ActionRename,
+		RunID:        journal.NewRunID(),
+		OriginalPath: original,
+		NewPath:      renamed,
+		ContentHash:  domain.ContentHash(content),
+		Model:        "test-model",
+	}
+	if err := j.Append(entry); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// Original name taken.
+	write(original, []byte("other"))
+	if err := j.Undo(fileSys, entry); !errors.Is(err, journal.ErrOriginalTaken) {
+		t.Errorf("Undo() error = %v, want %v", err, journal.ErrOriginalTaken)
+	}
+	if err := os.Remove(original); err != nil {
+		t.Fatal(err)
+	}
+
+	// Content changed.
+	write(renamed, []byte("edited"))
+	if err := j.Undo(fileSys, entry); !errors.Is(err, journal.ErrTargetChanged) {
+		t.Errorf("Undo() error = %v, want %v", err, journal.ErrTargetChanged)
+	}
+	write(renamed, content)
+
+	if err := j.Undo(fileSys, entry); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if !fileSys.Exists(original) || fileSys.Exists(renamed) {
+		t.Error("file was not renamed back")
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Model != "test-model" || entries[1].Action != journal.ActionUndo {
+		t.Errorf("Entries() = %+v, want rename followed by undo", entries)
+	}
+	if pending := journal.Pending(entries); len(pending) != 0 {
+		t.Errorf("Pending() = %+v, want none", pending)
+	}
+
+	// Target gone.
+	if err := j.Undo(fileSys, entry); !errors.Is(err, journal.ErrTargetMissing) {
+		t.Errorf("Undo() error = %v, want %v", err, journal.ErrTargetMissing)
+	}
+}
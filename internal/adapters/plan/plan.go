@@ -0,0 +1,120 @@
+// Package plan reads and writes rename plans: the proposals of a run, saved to
+// a JSON file so that they can be reviewed and edited before they are applied.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// Version is the current plan file format.
+const Version = 1
+
+// Plan is the content of a plan file.
+type Plan struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Model     string    `json:"model,omitempty"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry is one planned rename. Original is an absolute path, Proposed the new
+// name in the same directory, or in Dir if the file is moved when organizing.
+// ContentHash is the file's content at planning
+// time; the rename is refused if it has changed since.
+type Entry struct {
+	Original    string `json:"original"`
+	Proposed    string `json:"proposed"`
+	Dir         string `json:"dir,omitempty"`
+	Reasoning   string `json:"reasoning,omitempty"`
+	ContentHash string `json:"content_hash"`
+}
+
+// FromResults returns a plan with an entry for every proposed result.
+func FromResults(results []domain.RenameResult, model string) (Plan, error) {
+	p := Plan{Version: Version, CreatedAt: time.Now().UTC(), Model: model, Entries: []Entry{}}
+	for _, r := range results {
+		if r.Status != domain.StatusProposed {
+			continue
+		}
+		path, err := filepath.Abs(r.OriginalPath)
+		if err != nil {
+			return Plan{}, fmt.Errorf("failed to resolve %s: %w", r.OriginalPath, err)
+		}
+		var dir string
+		if r.TargetDir != "" {
+			if dir, err = filepath.Abs(r.TargetDir); err != nil {
+				return Plan{}, fmt.Errorf("failed to resolve %s: %w", r.TargetDir, err)
+			}
+		}
+		p.Entries = append(p.Entries, Entry{
+			Original:    path,
+			Proposed:    r.ProposedName,
+			Dir:         dir,
+			Reasoning:   r.Reasoning,
+			ContentHash: r.ContentHash,
+		})
+	}
+	return p, nil
+}
+
+// Results returns the entries as proposed results, in plan order.
+func (p Plan) Results() []domain.RenameResult {
+	results := make([]domain.RenameResult, len(p.Entries))
+	for i, e := range p.Entries {
+		results[i] = domain.RenameResult{
+			OriginalPath: e.Original,
+			OriginalName: filepath.Base(e.Original),
+			ProposedName: e.Proposed,
+			TargetDir:    e.Dir,
+			Reasoning:    e.Reasoning,
+			ContentHash:  e.ContentHash,
+			Status:       domain.StatusProposed,
+		}
+	}
+	return results
+}
+
+// Write saves the plan to path.
+func Write(path string, p Plan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write plan %s: %w", path, err)
+	}
+	return nil
+}
+
+// Read loads the plan at path and checks that every entry is complete.
+func Read(path string) (Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to read plan %s: %w", path, err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Plan{}, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if p.Version != Version {
+		return Plan{}, fmt.Errorf("plan %s has unsupported version %d (expected %d)", path, p.Version, Version)
+	}
+	for i, e := range p.Entries {
+		if e.Original == "" || e.Proposed == "" || e.ContentHash == "" {
+			return Plan{}, fmt.Errorf("plan %s entry %d: original, proposed and content_hash are required", path, i+1)
+		}
+		if !filepath.IsAbs(e.Original) {
+			return Plan{}, fmt.Errorf("plan %s entry %d: original must be an absolute path", path, i+1)
+		}
+		if e.Dir != "" && !filepath.IsAbs(e.Dir) {
+			return Plan{}, fmt.Errorf("plan %s entry %d: dir must be an absolute path", path, i+1)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,66 @@
+package plan_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/plan"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestPlan_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	results := []domain.RenameResult{
+		{OriginalPath: filepath.Join(dir, "a.pdf"), ProposedName: "invoice.pdf", TargetDir: filepath.Join(dir, "Invoices"), Reasoning: "an invoice", ContentHash: "h1", Status: domain.StatusProposed},
+		{OriginalPath: filepath.Join(dir, "b.pdf"), Status: domain.StatusFailed},
+		{OriginalPath: filepath.Join(dir, "c.pdf"), ProposedName: "c.pdf", Status: domain.StatusUnchanged},
+	}
+	p, err := plan.FromResults(results, "gemini-flash-latest")
+	if err != nil {
+		t.Fatalf("FromResults() error = %v", err)
+	}
+	path := filepath.Join(dir, "plan.json")
+	if err := plan.Write(path, p); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	read, err := plan.Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	got := read.Results()
+	if len(got) != 1 || read.Model != "gemini-flash-latest" {
+		t.Fatalf("Read() = %+v, want only the proposed entry", read)
+	}
+	if r := got[0]; r.OriginalName != "a.pdf" || r.ProposedName != "invoice.pdf" || r.ContentHash != "h1" || r.Status != domain.StatusProposed || r.NewPath() != filepath.Join(dir, "Invoices", "invoice.pdf") {
+		t.Errorf("Results()[0] = %+v", r)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not json", "{", "failed to parse"},
+		{"unknown version", `{"version": 9, "entries": []}`, "unsupported version"},
+		{"missing hash", `{"version": 1, "entries": [{"original": "/a.pdf", "proposed": "b.pdf"}]}`, "entry 1"},
+		{"relative path", `{"version": 1, "entries": [{"original": "a.pdf", "proposed": "b.pdf", "content_hash": "h"}]}`, "absolute"},
+	}
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "plan.json")
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := plan.Read(path); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: Read() error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
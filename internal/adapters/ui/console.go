@@ -5,7 +5,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
 )
 
 // ANSI Color codes
@@ -22,25 +30,66 @@ const (
 )
 
 type ConsoleUI struct {
-	reader io.Reader
+	// reader is shared by all prompts, so that input buffered by one prompt is
+	// not lost to the next.
+	reader *bufio.Reader
 	writer io.Writer
 }
 
+// NewConsoleUI returns a ConsoleUI on stdin and stdout. Colours are left out
+// if stdout is not a terminal or NO_COLOR is set.
 func NewConsoleUI() *ConsoleUI {
+	var w io.Writer = os.Stdout
+	if !IsTerminal(os.Stdout) || os.Getenv("NO_COLOR") != "" {
+		w = plainWriter{w: w}
+	}
 	return &ConsoleUI{
-		reader: os.Stdin,
-		writer: os.Stdout,
+		reader: bufio.NewReader(os.Stdin),
+		writer: &syncWriter{w: w},
 	}
 }
 
+// IsTerminal reports whether f is a terminal rather than a file, pipe or /dev/null.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
 // NewConsoleUIWithStreams allows creating a ConsoleUI with custom streams (for testing)
 func NewConsoleUIWithStreams(r io.Reader, w io.Writer) *ConsoleUI {
 	return &ConsoleUI{
-		reader: r,
-		writer: w,
+		reader: bufio.NewReader(r),
+		writer: &syncWriter{w: w},
 	}
 }
 
+// syncWriter serializes writes so that concurrent workers never interleave a line.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// ansiEscape matches the colour codes used by ConsoleUI.
+var ansiEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// plainWriter drops colour codes. Every message is written in one call, so a
+// code is never split between writes.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := p.w.Write(ansiEscape.ReplaceAll(b, nil)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
 func (ui *ConsoleUI) Info(msg string) {
 	_, _ = fmt.Fprintf(ui.writer, "%s> %s%s\n", Blue, msg, Reset)
 }
@@ -49,24 +98,93 @@ func (ui *ConsoleUI) PrintModelInfo(model string) {
 	_, _ = fmt.Fprintf(ui.writer, "%s> Using model: %s%s%s\n", Gray, Cyan, model, Reset)
 }
 
-func (ui *ConsoleUI) PrintDetectedType(mimeType string) {
+func (ui *ConsoleUI) PrintDetectedType(_, mimeType string) {
 	_, _ = fmt.Fprintf(ui.writer, "%s> Detected type: %s%s%s\n", Gray, Cyan, mimeType, Reset)
 }
 
-func (ui *ConsoleUI) PrintAnalyzing(filename string) {
-	_, _ = fmt.Fprintf(ui.writer, "%s> Analyzing '%s%s%s' with Gemini...%s\n", Gray, Bold, filename, Gray, Reset)
+func (ui *ConsoleUI) PrintAnalyzing(path string) {
+	_, _ = fmt.Fprintf(ui.writer, "%s> Analyzing '%s%s%s'...%s\n", Gray, Bold, filepath.Base(path), Gray, Reset)
+}
+
+// PrintProgress redraws one line with the progress of copying a large file to
+// another filesystem, ending it once the copy is complete.
+func (ui *ConsoleUI) PrintProgress(path string, copied, total int64) {
+	_, _ = fmt.Fprintf(ui.writer, "\r%s> Copying '%s' to another filesystem: %d%%%s", Gray, filepath.Base(path), copied*100/total, Reset)
+	if copied == total {
+		_, _ = fmt.Fprintln(ui.writer)
+	}
 }
 
-func (ui *ConsoleUI) PrintProposal(oldName, newName, reasoning string) {
+func (ui *ConsoleUI) PrintProposal(r domain.RenameResult) {
 	_, _ = fmt.Fprintf(ui.writer, "\n%s%sProposal %s\n", Purple, Bold, Reset)
-	_, _ = fmt.Fprintf(ui.writer, "%sReasoning:%s\n  %s\n\n", Bold, Reset, reasoning)
-	_, _ = fmt.Fprintf(ui.writer, "%sRename:%s\n  %s%s%s -> %s%s%s\n\n", Bold, Reset, Red, oldName, Reset, Green, newName, Reset)
+	_, _ = fmt.Fprintf(ui.writer, "%sReasoning:%s\n  %s\n\n", Bold, Reset, r.Reasoning)
+	if r.TargetDir == "" {
+		_, _ = fmt.Fprintf(ui.writer, "%sRename:%s\n  %s%s%s -> %s%s%s\n", Bold, Reset, Red, r.OriginalName, Reset, Green, r.ProposedName, Reset)
+	} else {
+		_, _ = fmt.Fprintf(ui.writer, "%sMove:%s\n  %s%s%s -> %s%s%s\n", Bold, Reset, Red, r.OriginalPath, Reset, Green, r.NewPath(), Reset)
+	}
+	if r.NewCategory != "" {
+		_, _ = fmt.Fprintf(ui.writer, "  %s(new category %s)%s\n", Yellow, r.NewCategory, Reset)
+	}
+	if r.Replaces {
+		_, _ = fmt.Fprintf(ui.writer, "  %s(replaces the existing file)%s\n", Yellow, Reset)
+	}
+	if r.Taken != "" {
+		_, _ = fmt.Fprintf(ui.writer, "  %s(%s is taken)%s\n", Yellow, r.Taken, Reset)
+	}
+	_, _ = fmt.Fprintln(ui.writer)
+}
+
+func (ui *ConsoleUI) PrintCollision(r domain.RenameResult, taken string) {
+	_, _ = fmt.Fprintf(ui.writer, "%s> %s is taken, proposing %s%s%s for '%s'%s\n", Gray, taken, Cyan, r.ProposedName, Gray, r.OriginalName, Reset)
+}
+
+func (ui *ConsoleUI) PrintReviewTable(results []domain.RenameResult) {
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.OriginalPath))
+	}
+
+	_, _ = fmt.Fprintf(ui.writer, "\n%s%sReview (%d files)%s\n", Purple, Bold, len(results), Reset)
+	for _, r := range results {
+		switch {
+		case r.Status == domain.StatusFailed:
+			_, _ = fmt.Fprintf(ui.writer, "  %-*s    %sfailed: %v%s\n", width, r.OriginalPath, Red, r.Err, Reset)
+		case r.Status == domain.StatusSkipped && r.Err != nil:
+			_, _ = fmt.Fprintf(ui.writer, "  %-*s    %sskipped: %v%s\n", width, r.OriginalPath, Yellow, r.Err, Reset)
+		case r.Status == domain.StatusSkipped:
+			_, _ = fmt.Fprintf(ui.writer, "  %-*s    %sskipped%s\n", width, r.OriginalPath, Yellow, Reset)
+		case r.Status == domain.StatusUnchanged:
+			_, _ = fmt.Fprintf(ui.writer, "  %-*s    %sunchanged%s\n", width, r.OriginalPath, Gray, Reset)
+		case r.Taken != "":
+			_, _ = fmt.Fprintf(ui.writer, "  %s%-*s%s -> %s%s%s %s(%s is taken)%s\n", Red, width, r.OriginalPath, Reset, Green, r.NewPath(), Reset, Yellow, r.Taken, Reset)
+		case r.Replaces:
+			_, _ = fmt.Fprintf(ui.writer, "  %s%-*s%s -> %s%s%s %s(replaces)%s\n", Red, width, r.OriginalPath, Reset, Green, r.NewPath(), Reset, Yellow, Reset)
+		default:
+			_, _ = fmt.Fprintf(ui.writer, "  %s%-*s%s -> %s%s%s\n", Red, width, r.OriginalPath, Reset, Green, r.NewPath(), Reset)
+		}
+	}
+	_, _ = fmt.Fprintln(ui.writer)
+}
+
+func (ui *ConsoleUI) PrintRenamed(r domain.RenameResult) {
+	if r.TargetDir != "" {
+		ui.PrintSuccess(r.NewPath())
+		return
+	}
+	ui.PrintSuccess(r.ProposedName)
 }
 
 func (ui *ConsoleUI) PrintSuccess(newName string) {
 	_, _ = fmt.Fprintf(ui.writer, "%s> Success! Renamed to %s%s%s\n", Green, Bold, newName, Reset)
 }
 
+// PrintResults does nothing: the console reports every stage as it happens.
+func (ui *ConsoleUI) PrintResults([]domain.RenameResult) {}
+
+// Close does nothing; it lets ConsoleUI be used wherever JSONUI is.
+func (ui *ConsoleUI) Close() error { return nil }
+
 func (ui *ConsoleUI) PrintDryRun() {
 	_, _ = fmt.Fprintf(ui.writer, "%s> Dry-run enabled. Skipping rename.%s\n", Yellow, Reset)
 }
@@ -76,16 +194,49 @@ func (ui *ConsoleUI) PrintCancelled() {
 }
 
 func (ui *ConsoleUI) Confirm(question string) (bool, error) {
-	_, _ = fmt.Fprintf(ui.writer, "%s%s [y/N]: %s", Bold, question, Reset)
-	reader := bufio.NewReader(ui.reader)
-	response, err := reader.ReadString('\n')
+	response, err := ui.Ask(question + " [y/N]")
 	if err != nil {
-		return false, fmt.Errorf("failed to read user input: %w", err)
+		return false, err
 	}
-	response = strings.ToLower(strings.TrimSpace(response))
+	response = strings.ToLower(response)
 	return response == "y" || response == "yes", nil
 }
 
+// Ask prints the question and returns the line the user typed, trimmed.
+func (ui *ConsoleUI) Ask(question string) (string, error) {
+	_, _ = fmt.Fprintf(ui.writer, "%s%s: %s", Bold, question, Reset)
+	response, err := ui.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || response == "") {
+		return "", fmt.Errorf("failed to read user input: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// PickCandidate lists the candidates with their confidence and asks for a
+// number, repeating the question until the answer is valid. "s" skips the file.
+func (ui *ConsoleUI) PickCandidate(file string, candidates []domain.Candidate) (int, error) {
+	_, _ = fmt.Fprintf(ui.writer, "\n%s%sCandidates for %s%s\n", Purple, Bold, file, Reset)
+	for i, c := range candidates {
+		_, _ = fmt.Fprintf(ui.writer, "  %s%d)%s %s%s%s %s(%.0f%%)%s\n", Bold, i+1, Reset, Green, filepath.Join(c.Dir, c.Name), Reset, Gray, c.Confidence*100, Reset)
+		if c.Reasoning != "" {
+			_, _ = fmt.Fprintf(ui.writer, "     %s\n", c.Reasoning)
+		}
+	}
+	for {
+		answer, err := ui.Ask(fmt.Sprintf("Choose [1-%d] or s to skip", len(candidates)))
+		if err != nil {
+			return -1, err
+		}
+		if strings.EqualFold(answer, "s") || strings.EqualFold(answer, "skip") {
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(candidates) {
+			return n - 1, nil
+		}
+		ui.Error(fmt.Sprintf("Unknown choice %q.", answer))
+	}
+}
+
 func (ui *ConsoleUI) Error(msg string) {
 	_, _ = fmt.Fprintf(ui.writer, "%sError: %s%s\n", Red, msg, Reset)
 }
@@ -2,10 +2,12 @@ package ui_test
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/domain"
 )
 
 func TestConsoleUI_Confirm(t *testing.T) {
@@ -81,6 +83,21 @@ func TestConsoleUI_Confirm(t *testing.T) {
 	}
 }
 
+func TestConsoleUI_Ask(t *testing.T) {
+	t.Parallel()
+
+	c := ui.NewConsoleUIWithStreams(strings.NewReader("r\n include the client  \ny"), &bytes.Buffer{})
+	for _, want := range []string{"r", "include the client", "y"} {
+		got, err := c.Ask("Choice")
+		if err != nil || got != want {
+			t.Errorf("Ask() = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := c.Ask("Choice"); err == nil {
+		t.Error("Ask() at end of input returned no error")
+	}
+}
+
 func TestConsoleUI_PrintProposal(t *testing.T) {
 	t.Parallel()
 
@@ -88,7 +105,7 @@ func TestConsoleUI_PrintProposal(t *testing.T) {
 	out := &bytes.Buffer{}
 	c := ui.NewConsoleUIWithStreams(in, out)
 
-	c.PrintProposal("old.txt", "new.txt", "because")
+	c.PrintProposal(domain.RenameResult{OriginalName: "old.txt", ProposedName: "new.txt", Reasoning: "because"})
 
 	output := out.String()
 	if !strings.Contains(output, "old.txt") {
@@ -115,3 +132,68 @@ func TestConsoleUI_Error(t *testing.T) {
 		t.Error("output missing error message")
 	}
 }
+
+func TestConsoleUI_PrintReviewTable(t *testing.T) {
+	t.Parallel()
+
+	in := strings.NewReader("")
+	out := &bytes.Buffer{}
+	c := ui.NewConsoleUIWithStreams(in, out)
+
+	c.PrintReviewTable([]domain.RenameResult{
+		{OriginalPath: "scan_001.pdf", ProposedName: "2024-01-01_invoice.pdf", Status: domain.StatusProposed},
+		{OriginalPath: "same.pdf", ProposedName: "same.pdf", Status: domain.StatusUnchanged},
+		{OriginalPath: "broken.bin", Status: domain.StatusFailed, Err: errors.New("unsupported file type")},
+		{OriginalPath: "taken.pdf", ProposedName: "memo.pdf", Status: domain.StatusSkipped, Err: errors.New("memo.pdf is taken")},
+		{OriginalPath: "declined.pdf", ProposedName: "letter.pdf", Status: domain.StatusSkipped},
+	})
+
+	output := out.String()
+	for _, want := range []string{
+		"5 files", "scan_001.pdf", "2024-01-01_invoice.pdf", "unchanged",
+		"failed: unsupported file type", "skipped: memo.pdf is taken", "declined.pdf    " + ui.Yellow + "skipped" + ui.Reset,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got %q", want, output)
+		}
+	}
+	if strings.Contains(output, "letter.pdf") {
+		t.Errorf("skipped file shown with its proposed name: %q", output)
+	}
+}
+
+func TestConsoleUI_PickCandidate(t *testing.T) {
+	t.Parallel()
+
+	candidates := []domain.Candidate{
+		{Name: "invoice.pdf", Reasoning: "an invoice", Confidence: 0.9},
+		{Name: "bill.pdf", Confidence: 0.45},
+	}
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"2\n", 1},
+		{"0\n3\nx\n1\n", 0},
+		{"s\n", -1},
+	}
+	for _, tc := range tests {
+		out := &bytes.Buffer{}
+		c := ui.NewConsoleUIWithStreams(strings.NewReader(tc.input), out)
+
+		got, err := c.PickCandidate("scan.pdf", candidates)
+		if err != nil || got != tc.want {
+			t.Errorf("PickCandidate(%q) = %d, %v, want %d", tc.input, got, err, tc.want)
+		}
+		for _, want := range []string{"1)", "invoice.pdf", "90%", "an invoice", "bill.pdf", "45%"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("output missing %q", want)
+			}
+		}
+	}
+
+	c := ui.NewConsoleUIWithStreams(strings.NewReader(""), &bytes.Buffer{})
+	if _, err := c.PickCandidate("scan.pdf", candidates); err == nil {
+		t.Error("PickCandidate() without input returned no error")
+	}
+}
@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// Output formats.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrNoInput is returned when a structured output format is asked for input.
+var ErrNoInput = errors.New("cannot ask for input with structured output")
+
+// Event types emitted by JSONUI.
+const (
+	EventModel     = "model"     // Model
+	EventDetected  = "detected"  // File, MimeType
+	EventAnalyzing = "analyzing" // File
+	EventProposal  = "proposal"  // File, Name, ProposedName, NewPath, NewCategory, Replaces, Taken, Reasoning, Confidence, Candidates, Status, Error
+	EventCollision = "collision" // File, Taken, ProposedName
+	EventProgress  = "progress"  // File, Copied, Total; while a large file is copied to another filesystem
+	EventRenamed   = "renamed"   // File, NewPath
+	EventResult    = "result"    // File, Name, ProposedName, NewPath, NewCategory, Replaces, Status, Error; one per file at the end
+	EventDryRun    = "dry_run"
+	EventCancelled = "cancelled"
+	EventInfo      = "info"  // Message
+	EventError     = "error" // Message
+)
+
+// Event is one structured output record. Field names are part of rnai's
+// interface and must not change; fields that do not apply are omitted.
+type Event struct {
+	Event        string              `json:"event"`
+	Time         time.Time           `json:"time"`
+	File         string              `json:"file,omitempty"`
+	Name         string              `json:"name,omitempty"`
+	MimeType     string              `json:"mime_type,omitempty"`
+	ProposedName string              `json:"proposed_name,omitempty"`
+	NewPath      string              `json:"new_path,omitempty"`
+	NewCategory  string              `json:"new_category,omitempty"`
+	Replaces     bool                `json:"replaces,omitempty"`
+	Taken        string              `json:"taken,omitempty"`
+	Copied       int64               `json:"copied,omitempty"`
+	Total        int64               `json:"total,omitempty"`
+	Reasoning    string              `json:"reasoning,omitempty"`
+	Confidence   *float64            `json:"confidence,omitempty"`
+	Candidates   []Candidate         `json:"candidates,omitempty"`
+	Status       domain.RenameStatus `json:"status,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	Model        string              `json:"model,omitempty"`
+	Message      string              `json:"message,omitempty"`
+}
+
+// Candidate is one of several names offered for a file.
+type Candidate struct {
+	Name       string  `json:"name"`
+	Dir        string  `json:"dir,omitempty"`
+	Reasoning  string  `json:"reasoning,omitempty"`
+	Confidence float64 `json:"confidence"`
+}
+
+// JSONUI emits every stage of the flow as an Event instead of coloured text.
+// With ndjson every event is written as one line as soon as it happens; with
+// json all events are collected and written as one array by Close. It never
+// prompts: questions fail with ErrNoInput.
+type JSONUI struct {
+	mu     sync.Mutex
+	writer io.Writer
+	stream bool
+	events []Event
+}
+
+// NewJSONUI returns a JSONUI writing to stdout in the given format, json or ndjson.
+func NewJSONUI(format string) (*JSONUI, error) {
+	return NewJSONUIWithWriter(format, os.Stdout)
+}
+
+// NewJSONUIWithWriter allows creating a JSONUI with a custom writer (for testing)
+func NewJSONUIWithWriter(format string, w io.Writer) (*JSONUI, error) {
+	switch format {
+	case FormatJSON, FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("unknown output format %q (valid: %s, %s, %s)", format, FormatText, FormatJSON, FormatNDJSON)
+	}
+	return &JSONUI{writer: w, stream: format == FormatNDJSON, events: []Event{}}, nil
+}
+
+func (ui *JSONUI) emit(e Event) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	e.Time = time.Now().UTC()
+	if !ui.stream {
+		ui.events = append(ui.events, e)
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	_, _ = ui.writer.Write(append(data, '\n'))
+}
+
+// Close writes the collected events in json format. It does nothing for ndjson.
+func (ui *JSONUI) Close() error {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if ui.stream {
+		return nil
+	}
+	enc := json.NewEncoder(ui.writer)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ui.events); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	ui.events = ui.events[:0]
+	return nil
+}
+
+func (ui *JSONUI) Info(msg string) {
+	ui.emit(Event{Event: EventInfo, Message: msg})
+}
+
+func (ui *JSONUI) PrintModelInfo(model string) {
+	ui.emit(Event{Event: EventModel, Model: model})
+}
+
+func (ui *JSONUI) PrintDetectedType(path, mimeType string) {
+	ui.emit(Event{Event: EventDetected, File: path, MimeType: mimeType})
+}
+
+func (ui *JSONUI) PrintAnalyzing(path string) {
+	ui.emit(Event{Event: EventAnalyzing, File: path})
+}
+
+func (ui *JSONUI) PrintProposal(r domain.RenameResult) {
+	e := resultEvent(EventProposal, r)
+	e.Reasoning = r.Reasoning
+	for _, c := range r.Candidates {
+		e.Candidates = append(e.Candidates, Candidate{Name: c.Name, Dir: c.Dir, Reasoning: c.Reasoning, Confidence: c.Confidence})
+	}
+	if r.Status == domain.StatusProposed || r.Status == domain.StatusUnchanged {
+		e.Confidence = &r.Confidence
+	}
+	ui.emit(e)
+}
+
+func (ui *JSONUI) PrintCollision(r domain.RenameResult, taken string) {
+	ui.emit(Event{Event: EventCollision, File: r.OriginalPath, Taken: taken, ProposedName: r.ProposedName})
+}
+
+func (ui *JSONUI) PrintReviewTable(results []domain.RenameResult) {
+	for _, r := range results {
+		ui.PrintProposal(r)
+	}
+}
+
+func (ui *JSONUI) PrintProgress(path string, copied, total int64) {
+	ui.emit(Event{Event: EventProgress, File: path, Copied: copied, Total: total})
+}
+
+func (ui *JSONUI) PrintRenamed(r domain.RenameResult) {
+	ui.emit(Event{Event: EventRenamed, File: r.OriginalPath, NewPath: r.NewPath()})
+}
+
+// PrintResults emits the final status of every file.
+func (ui *JSONUI) PrintResults(results []domain.RenameResult) {
+	for _, r := range results {
+		ui.emit(resultEvent(EventResult, r))
+	}
+}
+
+func (ui *JSONUI) PrintDryRun() {
+	ui.emit(Event{Event: EventDryRun})
+}
+
+func (ui *JSONUI) PrintCancelled() {
+	ui.emit(Event{Event: EventCancelled})
+}
+
+func (ui *JSONUI) Confirm(string) (bool, error) {
+	return false, ErrNoInput
+}
+
+func (ui *JSONUI) Ask(string) (string, error) {
+	return "", ErrNoInput
+}
+
+func (ui *JSONUI) PickCandidate(string, []domain.Candidate) (int, error) {
+	return -1, ErrNoInput
+}
+
+func (ui *JSONUI) Error(msg string) {
+	ui.emit(Event{Event: EventError, Message: msg})
+}
+
+func resultEvent(event string, r domain.RenameResult) Event {
+	e := Event{
+		Event:        event,
+		File:         r.OriginalPath,
+		Name:         r.OriginalName,
+		ProposedName: r.ProposedName,
+		NewCategory:  r.NewCategory,
+		Replaces:     r.Replaces,
+		Taken:        r.Taken,
+		Status:       r.Status,
+	}
+	if r.ProposedName != "" {
+		e.NewPath = r.NewPath()
+	}
+	if r.Err != nil {
+		e.Error = r.Err.Error()
+	}
+	return e
+}
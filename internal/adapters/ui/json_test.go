@@ -0,0 +1,127 @@
+package ui_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/ui"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// emitAll drives out through one file's flow, a plain rename in its directory.
+func emitAll(out *ui.JSONUI) {
+	r := domain.RenameResult{
+		OriginalPath: "scans/scan.pdf",
+		OriginalName: "scan.pdf",
+		ProposedName: "invoice-1.pdf",
+		Reasoning:    "an invoice",
+		Confidence:   0.75,
+		Status:       domain.StatusProposed,
+	}
+	out.PrintDetectedType(r.OriginalPath, "application/pdf")
+	out.PrintCollision(r, "invoice.pdf")
+	out.PrintReviewTable([]domain.RenameResult{r, {OriginalPath: "broken.bin", OriginalName: "broken.bin", Status: domain.StatusFailed, Err: errors.New("unsupported")}})
+	out.PrintRenamed(r)
+	r.Status = domain.StatusRenamed
+	out.PrintResults([]domain.RenameResult{r})
+}
+
+func TestJSONUI_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	out, err := ui.NewJSONUIWithWriter(ui.FormatNDJSON, buf)
+	if err != nil {
+		t.Fatalf("NewJSONUIWithWriter() error = %v", err)
+	}
+	emitAll(out)
+	if err := out.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+
+	want := []map[string]any{
+		{"event": "detected", "file": "scans/scan.pdf", "mime_type": "application/pdf"},
+		{"event": "collision", "file": "scans/scan.pdf", "taken": "invoice.pdf", "proposed_name": "invoice-1.pdf"},
+		{"event": "proposal", "file": "scans/scan.pdf", "name": "scan.pdf", "proposed_name": "invoice-1.pdf", "new_path": "scans/invoice-1.pdf", "reasoning": "an invoice", "confidence": 0.75, "status": "proposed"},
+		{"event": "proposal", "file": "broken.bin", "name": "broken.bin", "status": "failed", "error": "unsupported"},
+		{"event": "renamed", "file": "scans/scan.pdf", "new_path": "scans/invoice-1.pdf"},
+		{"event": "result", "file": "scans/scan.pdf", "name": "scan.pdf", "proposed_name": "invoice-1.pdf", "new_path": "scans/invoice-1.pdf", "status": "renamed"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, e := range events {
+		if _, ok := e["time"]; !ok {
+			t.Errorf("event %d has no time", i)
+		}
+		delete(e, "time")
+		if len(e) != len(want[i]) {
+			t.Errorf("event %d = %v, want %v", i, e, want[i])
+			continue
+		}
+		for k, v := range want[i] {
+			if e[k] != v {
+				t.Errorf("event %d: %s = %v, want %v", i, k, e[k], v)
+			}
+		}
+	}
+}
+
+func TestJSONUI_JSON(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	out, _ := ui.NewJSONUIWithWriter(ui.FormatJSON, buf)
+	emitAll(out)
+	if buf.Len() != 0 {
+		t.Fatalf("json format wrote before Close: %q", buf.String())
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var events []ui.Event
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(events) != 6 || events[0].Event != ui.EventDetected || events[5].Event != ui.EventResult {
+		t.Errorf("events = %+v", events)
+	}
+	if strings.Contains(buf.String(), "\033[") {
+		t.Error("output contains ANSI colour codes")
+	}
+}
+
+func TestJSONUI_NeverPrompts(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	out, _ := ui.NewJSONUIWithWriter(ui.FormatNDJSON, buf)
+	if _, err := out.Confirm("Rename?"); !errors.Is(err, ui.ErrNoInput) {
+		t.Errorf("Confirm() error = %v, want ErrNoInput", err)
+	}
+	if _, err := out.Ask("New name"); !errors.Is(err, ui.ErrNoInput) {
+		t.Errorf("Ask() error = %v, want ErrNoInput", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("prompt written: %q", buf.String())
+	}
+
+	if _, err := ui.NewJSONUIWithWriter("yaml", buf); err == nil {
+		t.Error("NewJSONUIWithWriter(yaml) returned no error")
+	}
+}
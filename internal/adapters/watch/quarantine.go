@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// FileSystem is the subset of filesystem operations needed to quarantine a file.
+type FileSystem interface {
+	Exists(path string) bool
+	Rename(oldPath, newPath string) error
+}
+
+// Quarantine moves the file at path into dir, keeping its name unless that is
+// taken, and returns its new path. If the name is taken just before the move,
+// fs.Rename fails with os.ErrExist and the next free name is tried.
+func Quarantine(fs FileSystem, path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	taken := make(map[string]bool)
+	for {
+		name := domain.ResolveCollision(filepath.Base(path), func(name string) bool {
+			return taken[name] || fs.Exists(filepath.Join(dir, name))
+		})
+		target := filepath.Join(dir, name)
+		err := fs.Rename(path, target)
+		if err == nil {
+			return target, nil
+		}
+		if !errors.Is(err, os.ErrExist) || len(taken) >= 10 {
+			return "", err
+		}
+		taken[name] = true
+	}
+}
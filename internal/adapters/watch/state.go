@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// StateFileName is the default name of the state file inside the watched directory.
+const StateFileName = ".rnai-watch.jsonl"
+
+// Record is a single line of the state file.
+type Record struct {
+	ContentHash string              `json:"content_hash"`
+	Path        string              `json:"path"`
+	Status      domain.RenameStatus `json:"status"`
+	Timestamp   time.Time           `json:"timestamp"`
+}
+
+// State is an append-only JSON Lines log of processed content. It is keyed by
+// content hash rather than path, so neither a restarted watcher nor the events
+// caused by its own renames process a file twice.
+type State struct {
+	mu   sync.Mutex
+	path string
+	seen map[string]struct{}
+}
+
+// OpenState reads the state file at path. A missing file is an empty state.
+func OpenState(path string) (*State, error) {
+	s := &State{path: path, seen: make(map[string]struct{})}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watch state %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("watch state %s line %d: %w", path, line, err)
+		}
+		s.seen[r.ContentHash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read watch state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Seen reports whether content with this hash has been processed.
+func (s *State) Seen(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[hash]
+	return ok
+}
+
+// Add marks the content as processed and persists the record.
+func (s *State) Add(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now().UTC()
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode watch state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create watch state directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open watch state %s: %w", s.path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write watch state %s: %w", s.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write watch state %s: %w", s.path, err)
+	}
+	s.seen[r.ContentHash] = struct{}{}
+	return nil
+}
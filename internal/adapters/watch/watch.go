@@ -0,0 +1,140 @@
+// Package watch turns filesystem events in an inbox directory into files that
+// are ready to be processed, and remembers which content has been processed.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultSettle is how long a file's size and modification time must stay
+// unchanged before it is considered fully written.
+const DefaultSettle = 2 * time.Second
+
+// Watcher reports the files in a single directory once they are complete.
+// Subdirectories and hidden files are ignored.
+type Watcher struct {
+	dir    string
+	settle time.Duration
+	poll   time.Duration
+}
+
+func New(dir string, settle time.Duration) *Watcher {
+	return &Watcher{dir: dir, settle: settle, poll: max(settle/4, 10*time.Millisecond)}
+}
+
+// snapshot is what a pending file looked like when it last changed.
+type snapshot struct {
+	size    int64
+	modTime time.Time
+	since   time.Time
+}
+
+// Run calls ready for every regular, non-empty file in the directory, first for
+// the files already present and then for new ones, as soon as the file has not
+// changed for the settle duration. A file that is written to again is reported
+// again. ready is called from Run's goroutine, one file at a time, so events
+// arriving meanwhile are picked up afterwards. Run blocks until ctx is done.
+func (w *Watcher) Run(ctx context.Context, ready func(path string)) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer func() { _ = fw.Close() }()
+	if err := fw.Add(w.dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", w.dir, err)
+	}
+
+	pending := make(map[string]snapshot)
+	track := func(path string) {
+		if !strings.HasPrefix(filepath.Base(path), ".") {
+			pending[path] = snapshot{}
+		}
+	}
+	scan := func() error {
+		entries, err := os.ReadDir(w.dir)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", w.dir, err)
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() {
+				track(filepath.Join(w.dir, e.Name()))
+			}
+		}
+		return nil
+	}
+	if err := scan(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) {
+				track(ev.Name)
+			}
+			if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				delete(pending, ev.Name)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			// Events were lost; the directory listing tells us what we missed.
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				if err := scan(); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("failed to watch %s: %w", w.dir, err)
+		case now := <-ticker.C:
+			for _, path := range w.settled(pending, now) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				ready(path)
+			}
+		}
+	}
+}
+
+// settled updates the snapshots of the pending files and removes and returns,
+// sorted, those that have not changed for the settle duration.
+func (w *Watcher) settled(pending map[string]snapshot, now time.Time) []string {
+	var paths []string
+	for path, last := range pending {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			delete(pending, path)
+			continue
+		}
+		if last.since.IsZero() || info.Size() != last.size || !info.ModTime().Equal(last.modTime) {
+			pending[path] = snapshot{size: info.Size(), modTime: info.ModTime(), since: now}
+			continue
+		}
+		// Scanners often create the file before writing to it.
+		if info.Size() == 0 || now.Sub(last.since) < w.settle {
+			continue
+		}
+		delete(pending, path)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
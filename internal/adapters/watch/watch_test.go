@@ -0,0 +1,129 @@
+package watch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/fs"
+	"github.com/maltehedderich/rename-ai/internal/adapters/watch"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_ReportsCompleteFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "existing.pdf"), "existing")
+	writeFile(t, filepath.Join(dir, ".hidden.pdf"), "hidden")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ready := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watch.New(dir, 100*time.Millisecond).Run(ctx, func(path string) { ready <- path })
+	}()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case path := <-ready:
+			return filepath.Base(path)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a file")
+			return ""
+		}
+	}
+	if got := next(); got != "existing.pdf" {
+		t.Errorf("first file = %s, want existing.pdf", got)
+	}
+
+	// A file that keeps growing is only reported once it has settled.
+	scan := filepath.Join(dir, "scan.pdf")
+	f, err := os.Create(scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = f.WriteString("page ")
+	}
+	_ = f.Close()
+	written := time.Now()
+	if got := next(); got != "scan.pdf" {
+		t.Errorf("second file = %s, want scan.pdf", got)
+	}
+	if elapsed := time.Since(written); elapsed < 100*time.Millisecond {
+		t.Errorf("scan.pdf reported %v after the last write, before it had settled", elapsed)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	select {
+	case path := <-ready:
+		t.Errorf("unexpected file %s", path)
+	default:
+	}
+}
+
+func TestState_SurvivesReopen(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state", watch.StateFileName)
+	state, err := watch.OpenState(path)
+	if err != nil {
+		t.Fatalf("OpenState() error = %v", err)
+	}
+	if state.Seen("abc") {
+		t.Fatal("Seen() on an empty state")
+	}
+	if err := state.Add(watch.Record{ContentHash: "abc", Path: "/inbox/a.pdf", Status: domain.StatusRenamed}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	reopened, err := watch.OpenState(path)
+	if err != nil {
+		t.Fatalf("OpenState() error = %v", err)
+	}
+	if !reopened.Seen("abc") || reopened.Seen("def") {
+		t.Error("reopened state does not match what was added")
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	quarantine := filepath.Join(dir, "quarantine")
+	if err := os.Mkdir(quarantine, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(quarantine, "bad.bin"), "earlier")
+	writeFile(t, filepath.Join(dir, "bad.bin"), "new")
+
+	moved, err := watch.Quarantine(fs.NewOsFileSystem(), filepath.Join(dir, "bad.bin"), quarantine)
+	if err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+	if moved != filepath.Join(quarantine, "bad-1.bin") {
+		t.Errorf("Quarantine() = %s, want bad-1.bin in the quarantine directory", moved)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.bin")); !os.IsNotExist(err) {
+		t.Error("file still in the inbox")
+	}
+}
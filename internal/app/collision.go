@@ -0,0 +1,192 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// ErrReplacedChanged: the file a proposal was to replace has changed since
+// the replacement was decided.
+var ErrReplacedChanged = errors.New("file to be replaced has changed since it was reviewed")
+
+// resolveCollisions settles, by the collision strategy, every proposal whose
+// name is taken in its target directory. Names chosen earlier in the batch are
+// reserved, so two files proposing the same name never end up targeting the
+// same path. A file may always keep its own name. Names still awaiting
+// approval are resolved again from the name that was taken.
+func (s *Service) resolveCollisions(results []domain.RenameResult) {
+	reserved := make(map[string]int) // path -> index of the result that claimed it
+	for i := range results {
+		r := &results[i]
+		if r.Status != domain.StatusProposed {
+			continue
+		}
+		r.Replaces, r.ReplacedHash = false, ""
+		if r.Taken != "" {
+			r.ProposedName, r.Taken = r.Taken, ""
+		}
+		dir := r.Dir()
+		taken := func(name string) bool {
+			path := filepath.Join(dir, name)
+			if _, ok := reserved[path]; ok {
+				return true
+			}
+			return path != filepath.Clean(r.OriginalPath) && s.fs.Exists(path)
+		}
+		if taken(r.ProposedName) {
+			owner, ok := reserved[r.NewPath()]
+			if !ok {
+				owner = -1
+			}
+			s.collide(results, i, owner, taken)
+			if r.Status != domain.StatusProposed {
+				continue
+			}
+		}
+		reserved[r.NewPath()] = i
+		if r.NewPath() == filepath.Clean(r.OriginalPath) {
+			r.Status = domain.StatusUnchanged
+		}
+	}
+}
+
+// collide settles the proposal results[i], whose name is taken by a file on
+// disk or by results[owner], a proposal earlier in the batch (owner is -1 if
+// none is). taken reports whether a name is taken in the target directory.
+func (s *Service) collide(results []domain.RenameResult, i, owner int, taken func(string) bool) {
+	r := &results[i]
+	wanted := r.ProposedName
+	switch s.collision {
+	case domain.CollisionSkip:
+		skipTaken(r, wanted)
+		return
+	case domain.CollisionAsk:
+		// The next free name is reserved now and asked about in Review, once
+		// all proposals have been shown.
+		r.ProposedName, r.Taken = domain.ResolveCollision(wanted, taken), wanted
+		return
+	case domain.CollisionDedupe:
+		hash, err := s.replacedHash(results, i, owner)
+		if err != nil {
+			*r = failed(*r, err)
+			return
+		}
+		if hash == r.ContentHash {
+			r.Replaces, r.ReplacedHash = true, hash
+			return
+		}
+		r.ProposedName = domain.ResolveCollision(wanted, taken)
+	case domain.CollisionKeepNewer:
+		newer, err := s.isNewer(results, i, owner)
+		if err != nil {
+			*r = failed(*r, err)
+			return
+		}
+		if !newer {
+			r.Status = domain.StatusSkipped
+			r.Err = fmt.Errorf("%w: %s is not older than the file named so", ErrTargetTaken, wanted)
+			return
+		}
+		if owner >= 0 {
+			results[owner].Status = domain.StatusSkipped
+			results[owner].Err = fmt.Errorf("%w: %s is newer", ErrTargetTaken, r.OriginalName)
+		}
+		if !s.fs.Exists(r.NewPath()) {
+			return
+		}
+		hash, err := s.fs.HashFile(r.NewPath())
+		if err != nil {
+			*r = failed(*r, err)
+			return
+		}
+		r.Replaces, r.ReplacedHash = true, hash
+		return
+	case domain.CollisionHash:
+		r.ProposedName = domain.ResolveCollision(domain.HashSuffixName(wanted, r.ContentHash), taken)
+	default:
+		r.ProposedName = domain.ResolveCollision(wanted, taken)
+	}
+	s.ui.PrintCollision(*r, wanted)
+}
+
+// approveTakenNames asks, for every proposal whose name was taken, whether the
+// next free name may be used instead. Under a policy nobody can be asked, so
+// such files are skipped with ErrTargetTaken, as are declined ones.
+func (s *Service) approveTakenNames(results []domain.RenameResult) error {
+	for i := range results {
+		r := &results[i]
+		if r.Status != domain.StatusProposed || r.Taken == "" {
+			continue
+		}
+		approved := false
+		if s.policy == nil {
+			var err error
+			question := fmt.Sprintf("%s is taken. Rename %s to %s instead?", r.Taken, r.OriginalName, r.ProposedName)
+			if approved, err = s.ui.Confirm(question); err != nil {
+				return fmt.Errorf("input error: %w", err)
+			}
+		}
+		if approved {
+			r.Taken = ""
+			continue
+		}
+		skipTaken(r, r.Taken)
+		s.ui.Info(fmt.Sprintf("%s: skipped, %v", r.OriginalName, r.Err))
+	}
+	return nil
+}
+
+func skipTaken(r *domain.RenameResult, name string) {
+	r.Status = domain.StatusSkipped
+	r.Err = fmt.Errorf("%w: %s", ErrTargetTaken, name)
+}
+
+// replacedHash returns the content hash of the file results[i] collides with:
+// results[owner], or the file on disk if owner is -1.
+func (s *Service) replacedHash(results []domain.RenameResult, i, owner int) (string, error) {
+	if owner >= 0 {
+		return results[owner].ContentHash, nil
+	}
+	return s.fs.HashFile(results[i].NewPath())
+}
+
+// isNewer reports whether results[i] was modified later than the file it
+// collides with: results[owner], or the file on disk if owner is -1.
+func (s *Service) isNewer(results []domain.RenameResult, i, owner int) (bool, error) {
+	r := results[i]
+	other := r.NewPath()
+	if owner >= 0 {
+		other = results[owner].OriginalPath
+	}
+	mtime, err := s.fs.ModTime(r.OriginalPath)
+	if err != nil {
+		return false, err
+	}
+	otherTime, err := s.fs.ModTime(other)
+	if err != nil {
+		return false, err
+	}
+	return mtime.After(otherTime), nil
+}
+
+// replace moves r over the file at its new path, but only if that file still
+// has the content it had when the replacement was decided, so that nothing
+// is overwritten that was not reviewed. If the file is gone, r is renamed
+// without replacing anything.
+func (s *Service) replace(r domain.RenameResult) error {
+	target := r.NewPath()
+	if !s.fs.Exists(target) {
+		return s.fs.Rename(r.OriginalPath, target)
+	}
+	hash, err := s.fs.HashFile(target)
+	if err != nil {
+		return err
+	}
+	if hash != r.ReplacedHash {
+		return fmt.Errorf("%w: %s", ErrReplacedChanged, r.ProposedName)
+	}
+	return s.fs.Replace(r.OriginalPath, target)
+}
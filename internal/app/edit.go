@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// editSeparator separates the current path from the new name in the editor.
+const editSeparator = " -> "
+
+const editHeader = `# Edit the new names on the right, then save and quit.
+# Delete a line to skip that file. Do not change the paths on the left.
+# Lines starting with '#' are ignored.
+`
+
+// Edit lets the user change the proposals in the editor, one "path -> name"
+// line per file, vidir-style. Edited names are sanitized in the service's style
+// and collisions are resolved again; the choice among candidates is made here
+// too. Files whose line was deleted are skipped.
+// If the edited text cannot be parsed, the results are left unchanged.
+func (s *Service) Edit(results []domain.RenameResult) error {
+	if s.editor == nil {
+		return fmt.Errorf("no editor configured")
+	}
+
+	var b strings.Builder
+	b.WriteString(editHeader)
+	editable := make(map[string]int)
+	for i, r := range results {
+		if r.Status != domain.StatusProposed && r.Status != domain.StatusUnchanged {
+			continue
+		}
+		editable[r.OriginalPath] = i
+		fmt.Fprintf(&b, "%s%s%s\n", r.OriginalPath, editSeparator, r.ProposedName)
+	}
+	if len(editable) == 0 {
+		return nil
+	}
+
+	edited, err := s.editor.Edit(b.String())
+	if err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	names, err := s.parseEdited(edited, editable)
+	if err != nil {
+		return err
+	}
+
+	for path, i := range editable {
+		r := &results[i]
+		name, ok := names[path]
+		if !ok {
+			r.Status = domain.StatusSkipped
+			continue
+		}
+		if name != r.ProposedName {
+			// The user's own name is not subject to the minimum confidence
+			// and needs no approval.
+			r.Confidence = 1
+			r.Taken = ""
+		}
+		r.ProposedName = name
+		r.Candidates = nil
+		r.Status = domain.StatusProposed
+	}
+	s.resolveCollisions(results)
+	return nil
+}
+
+// parseEdited returns the sanitized new name for every path listed in the text.
+// Paths are matched exactly, as they may start or end with spaces; only the
+// carriage return some editors end lines with is removed.
+func (s *Service) parseEdited(text string, editable map[string]int) (map[string]string, error) {
+	names := make(map[string]string)
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		path, name, ok := cutEdited(line, editable)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'path%sname'", n+1, editSeparator)
+		}
+		if _, ok := editable[path]; !ok {
+			return nil, fmt.Errorf("line %d: unknown file %q", n+1, path)
+		}
+		if _, ok := names[path]; ok {
+			return nil, fmt.Errorf("line %d: %s is listed twice", n+1, path)
+		}
+		name, err := domain.RepairName(s.style.Sanitize(s.translit.Apply(strings.TrimSpace(name))))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n+1, path, err)
+		}
+		names[path] = name
+	}
+	return names, nil
+}
+
+// cutEdited splits a line into path and name. Paths and names may contain the
+// separator themselves, so the line is split at the last separator that follows
+// a listed path, or at the last separator if none does.
+func cutEdited(line string, editable map[string]int) (path, name string, ok bool) {
+	last := strings.LastIndex(line, editSeparator)
+	if last < 0 {
+		return "", "", false
+	}
+	for i := last; i >= 0; i = strings.LastIndex(line[:i], editSeparator) {
+		if _, listed := editable[line[:i]]; listed {
+			return line[:i], line[i+len(editSeparator):], true
+		}
+	}
+	return line[:last], line[last+len(editSeparator):], true
+}
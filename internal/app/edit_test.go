@@ -0,0 +1,151 @@
+package app_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// fakeEditor replaces the text with the result of edit.
+type fakeEditor struct {
+	edit func(text string) string
+	seen string
+}
+
+func (e *fakeEditor) Edit(text string) (string, error) {
+	e.seen = text
+	return e.edit(text), nil
+}
+
+func TestService_Edit(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf", "c.pdf", "x.bin", "2024-01-01_taken.pdf"}
+	subjects := map[string]string{
+		"content of a.pdf":                "Invoice",
+		"content of b.pdf":                "Letter",
+		"content of c.pdf":                "Memo",
+		"content of 2024-01-01_taken.pdf": "Taken",
+	}
+
+	tests := []struct {
+		name      string
+		edit      func(string) string
+		wantErr   string
+		wantNames []string
+		wantState []domain.RenameStatus
+	}{
+		{
+			name: "rename, sanitize, skip and collide",
+			edit: func(text string) string {
+				text = strings.Replace(text, "2024-01-01_invoice.pdf", "Client Invoice.pdf", 1)
+				text = strings.Replace(text, "2024-01-01_letter.pdf", "2024-01-01_taken.pdf", 1)
+				return strings.Replace(text, "c.pdf -> 2024-01-01_memo.pdf\n", "", 1)
+			},
+			wantNames: []string{"client-invoice.pdf", "2024-01-01_taken-1.pdf", "2024-01-01_memo.pdf", "", "2024-01-01_taken.pdf"},
+			wantState: []domain.RenameStatus{domain.StatusProposed, domain.StatusProposed, domain.StatusSkipped, domain.StatusFailed, domain.StatusUnchanged},
+		},
+		{
+			name:    "unknown path",
+			edit:    func(text string) string { return text + "z.pdf -> z.pdf\n" },
+			wantErr: "unknown file",
+		},
+		{
+			name:    "missing separator",
+			edit:    func(text string) string { return strings.Replace(text, " -> ", " => ", 1) },
+			wantErr: "expected",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ed := &fakeEditor{edit: tc.edit}
+			svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, &fakeUI{}, app.WithEditor(ed))
+			results := svc.Propose(t.Context(), requests(files...))
+			before := append([]domain.RenameResult(nil), results...)
+
+			err := svc.Edit(results)
+			if strings.Contains(ed.seen, "x.bin") {
+				t.Errorf("failed file offered for editing:\n%s", ed.seen)
+			}
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("Edit() error = %v, want %q", err, tc.wantErr)
+				}
+				for i := range results {
+					if results[i].ProposedName != before[i].ProposedName || results[i].Status != before[i].Status {
+						t.Errorf("results[%d] changed despite the error: %+v", i, results[i])
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Edit() error = %v", err)
+			}
+			for i, r := range results {
+				if r.Status != tc.wantState[i] || r.ProposedName != tc.wantNames[i] {
+					t.Errorf("results[%d] = %s %q, want %s %q", i, r.Status, r.ProposedName, tc.wantState[i], tc.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestService_EditSeparatorInName(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"draft -> final.pdf", "a.pdf"}
+	subjects := map[string]string{
+		"content of draft -> final.pdf": "Report",
+		"content of a.pdf":              "Invoice",
+	}
+	ed := &fakeEditor{edit: func(text string) string {
+		text = strings.Replace(text, "2024-01-01_report.pdf", "Final Report.pdf", 1)
+		return strings.Replace(text, "2024-01-01_invoice.pdf", "Draft -> Invoice.pdf", 1)
+	}}
+	svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, &fakeUI{}, app.WithEditor(ed))
+	results := svc.Propose(t.Context(), requests(files...))
+
+	if err := svc.Edit(results); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	want := []string{"final-report.pdf", "draft-invoice.pdf"}
+	for i, r := range results {
+		if r.Status != domain.StatusProposed || r.ProposedName != want[i] {
+			t.Errorf("results[%d] = %s %q, want %s %q", i, r.Status, r.ProposedName, domain.StatusProposed, want[i])
+		}
+	}
+}
+
+func TestService_EditPathsWithSpaces(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"memo.pdf", " memo.pdf", "  memo.pdf"}
+	subjects := map[string]string{
+		"content of memo.pdf":   "Memo",
+		"content of  memo.pdf":  "Letter",
+		"content of   memo.pdf": "Note",
+	}
+	ed := &fakeEditor{edit: func(text string) string {
+		text = strings.Replace(text, "2024-01-01_memo.pdf", "first.pdf", 1)
+		text = strings.Replace(text, "2024-01-01_letter.pdf", "second.pdf  ", 1)
+		text = strings.Replace(text, "2024-01-01_note.pdf", "third.pdf", 1)
+		// Saved with Windows line endings.
+		return strings.ReplaceAll(text, "\n", "\r\n")
+	}}
+	svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, &fakeUI{}, app.WithEditor(ed))
+	results := svc.Propose(t.Context(), requests(files...))
+
+	if err := svc.Edit(results); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	want := []string{"first.pdf", "second.pdf", "third.pdf"}
+	for i, r := range results {
+		if r.Status != domain.StatusProposed || r.ProposedName != want[i] {
+			t.Errorf("results[%d] (%q) = %s %q, want %s %q", i, r.OriginalPath, r.Status, r.ProposedName, domain.StatusProposed, want[i])
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+const reviewChoices = "[y]es, [s]kip, [e]dit, [r]egenerate, [a]ll remaining, [q]uit"
+
+// ReviewEach presents the proposals one at a time. Where the AI offered several
+// candidates, the user picks one first. For each, the user then accepts it,
+// skips it, edits the name, regenerates it with a hint for the model, accepts
+// it and all remaining proposals, or quits, which skips the whole batch.
+// Accepting all remaining does not cover proposals with a new category or with
+// a replacement for a taken name; those are still shown one at a time.
+// Accepted proposals stay proposed, everything else is marked as skipped. The
+// minimum confidence does not apply, as the user sees every proposal. It
+// returns true if there is anything to rename. In dry-run mode it behaves like
+// Review.
+func (s *Service) ReviewEach(ctx context.Context, results []domain.RenameResult) (bool, error) {
+	if s.dryRun {
+		return s.Review(results)
+	}
+
+	acceptAll := false
+	for i := range results {
+		r := &results[i]
+		if r.Err != nil {
+			s.ui.Error(fmt.Sprintf("%s: %v", r.OriginalName, r.Err))
+			continue
+		}
+
+		var history []domain.Revision
+		picked := false
+		for decided := acceptAll && r.NewCategory == "" && r.Taken == ""; !decided && r.Status == domain.StatusProposed; {
+			if len(r.Candidates) > 1 && !picked {
+				picked = true
+				if err := s.pick(r); err != nil {
+					skipProposed(results)
+					return false, err
+				}
+				s.resolveCollisions(results)
+				continue
+			}
+			s.ui.PrintProposal(*r)
+			answer, err := s.ui.Ask("Rename? " + reviewChoices)
+			if err != nil {
+				skipProposed(results)
+				return false, fmt.Errorf("input error: %w", err)
+			}
+
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				decided, r.Taken = true, ""
+			case "s", "skip", "n", "no":
+				r.Status = domain.StatusSkipped
+			case "a", "all":
+				decided, acceptAll, r.Taken = true, true, ""
+			case "q", "quit":
+				s.ui.PrintCancelled()
+				skipProposed(results)
+				return false, nil
+			case "e", "edit":
+				if err := s.editName(results, i); err != nil {
+					s.ui.Error(err.Error())
+				}
+			case "r", "regenerate":
+				hint, err := s.ui.Ask("Hint for the model")
+				if err != nil {
+					skipProposed(results)
+					return false, fmt.Errorf("input error: %w", err)
+				}
+				history = append(history, domain.Revision{
+					Suggestion: domain.NameSuggestion{Fields: r.Fields, Reasoning: r.Reasoning, Confidence: r.Confidence},
+					Name:       r.ProposedName,
+					Hint:       hint,
+				})
+				if err := s.regenerate(ctx, results, i, history); err != nil {
+					history = history[:len(history)-1]
+					s.ui.Error(err.Error())
+				} else {
+					picked = false
+				}
+			default:
+				s.ui.Error(fmt.Sprintf("Unknown choice %q. Choose one of %s.", answer, reviewChoices))
+			}
+		}
+		if r.Status == domain.StatusUnchanged {
+			s.ui.Info(fmt.Sprintf("%s keeps its name.", r.OriginalName))
+		}
+	}
+
+	if Count(results, domain.StatusProposed) == 0 {
+		s.ui.Info("Nothing to rename.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// editName replaces the proposal of results[i] with a name typed by the user.
+func (s *Service) editName(results []domain.RenameResult, i int) error {
+	answer, err := s.ui.Ask("New name")
+	if err != nil {
+		return fmt.Errorf("input error: %w", err)
+	}
+	name, err := domain.RepairName(s.style.Sanitize(s.translit.Apply(answer)))
+	if err != nil {
+		return fmt.Errorf("%w, keeping %s", err, results[i].ProposedName)
+	}
+	results[i].ProposedName = name
+	results[i].Taken = ""
+	results[i].Candidates = nil
+	s.resolveCollisions(results)
+	return nil
+}
+
+// regenerate asks the model for a new proposal for results[i], passing the
+// earlier proposals and the user's hints along.
+func (s *Service) regenerate(ctx context.Context, results []domain.RenameResult, i int, history []domain.Revision) error {
+	r := results[i]
+	content, err := s.fs.ReadFile(r.OriginalPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	ext, _ := s.extPolicy.Extension(r.OriginalPath, r.MimeType)
+	if err := s.suggest(ctx, &r, content, ext, history); err != nil {
+		return err
+	}
+	results[i] = r
+	s.resolveCollisions(results)
+	return nil
+}
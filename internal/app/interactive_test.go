@@ -0,0 +1,124 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestService_ReviewEach(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf", "c.pdf"}
+	subjects := map[string]string{
+		"content of a.pdf": "Invoice",
+		"content of b.pdf": "Letter",
+		"content of c.pdf": "Memo",
+	}
+
+	tests := []struct {
+		name          string
+		answers       []string
+		wantConfirmed bool
+		wantStatus    []domain.RenameStatus
+		wantNames     []string
+	}{
+		{
+			name:          "accept, skip, accept",
+			answers:       []string{"y", "s", "yes"},
+			wantConfirmed: true,
+			wantStatus:    []domain.RenameStatus{domain.StatusProposed, domain.StatusSkipped, domain.StatusProposed},
+			wantNames:     []string{"2024-01-01_invoice.pdf", "2024-01-01_letter.pdf", "2024-01-01_memo.pdf"},
+		},
+		{
+			name:          "edit and regenerate twice",
+			answers:       []string{"e", "Client Invoice.pdf", "y", "r", "ACME", "r", "2024", "y", "n"},
+			wantConfirmed: true,
+			wantStatus:    []domain.RenameStatus{domain.StatusProposed, domain.StatusProposed, domain.StatusSkipped},
+			wantNames:     []string{"client-invoice.pdf", "2024-01-01_letter-acme-2024.pdf", "2024-01-01_memo.pdf"},
+		},
+		{
+			name:          "accept all remaining",
+			answers:       []string{"s", "a"},
+			wantConfirmed: true,
+			wantStatus:    []domain.RenameStatus{domain.StatusSkipped, domain.StatusProposed, domain.StatusProposed},
+		},
+		{
+			name:       "quit skips everything",
+			answers:    []string{"y", "q"},
+			wantStatus: []domain.RenameStatus{domain.StatusSkipped, domain.StatusSkipped, domain.StatusSkipped},
+		},
+		{
+			name:       "unknown choice asks again",
+			answers:    []string{"x", "s", "s", "s"},
+			wantStatus: []domain.RenameStatus{domain.StatusSkipped, domain.StatusSkipped, domain.StatusSkipped},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui := &fakeUI{answers: tc.answers}
+			svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, ui)
+			results := svc.Propose(context.Background(), requests(files...))
+
+			confirmed, err := svc.ReviewEach(context.Background(), results)
+			if err != nil {
+				t.Fatalf("ReviewEach() error = %v", err)
+			}
+			if confirmed != tc.wantConfirmed {
+				t.Errorf("ReviewEach() = %v, want %v", confirmed, tc.wantConfirmed)
+			}
+			for i, r := range results {
+				if r.Status != tc.wantStatus[i] {
+					t.Errorf("results[%d].Status = %s, want %s", i, r.Status, tc.wantStatus[i])
+				}
+				if tc.wantNames != nil && r.ProposedName != tc.wantNames[i] {
+					t.Errorf("results[%d].ProposedName = %s, want %s", i, r.ProposedName, tc.wantNames[i])
+				}
+			}
+			if len(ui.answers) != 0 {
+				t.Errorf("unused answers %v", ui.answers)
+			}
+		})
+	}
+}
+
+func TestService_ReviewEachInputError(t *testing.T) {
+	t.Parallel()
+
+	svc := app.NewService(newFakeFS("a.pdf"), &fakeAI{subjects: map[string]string{"content of a.pdf": "Invoice"}}, &fakeUI{})
+	results := svc.Propose(context.Background(), requests("a.pdf"))
+	if _, err := svc.ReviewEach(context.Background(), results); err == nil {
+		t.Fatal("ReviewEach() without input returned no error")
+	}
+	if results[0].Status != domain.StatusSkipped {
+		t.Errorf("Status = %s, want skipped", results[0].Status)
+	}
+}
+
+func TestService_ReviewEachCandidates(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf"}
+	subjects := map[string]string{"content of a.pdf": "Invoice", "content of b.pdf": "Letter"}
+	// a.pdf: pick the alternative, accept. b.pdf: pick the best, regenerate, pick again, accept.
+	ui := &fakeUI{answers: []string{"y", "r", "ACME", "y"}, picks: []int{1, 0, 1}}
+	svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, ui, app.WithCandidates(2))
+	results := svc.Propose(context.Background(), requests(files...))
+
+	if _, err := svc.ReviewEach(context.Background(), results); err != nil {
+		t.Fatalf("ReviewEach() error = %v", err)
+	}
+	want := []string{"2024-01-01_invoice-alt-1.pdf", "2024-01-01_letter-acme-alt-1.pdf"}
+	for i, r := range results {
+		if r.Status != domain.StatusProposed || r.ProposedName != want[i] {
+			t.Errorf("results[%d] = %s %q, want proposed %q", i, r.Status, r.ProposedName, want[i])
+		}
+	}
+	if len(ui.picks) != 0 || len(ui.answers) != 0 {
+		t.Errorf("unused picks %v, answers %v", ui.picks, ui.answers)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// Policy decides without asking whether a proposal is applied.
+type Policy func(r domain.RenameResult) bool
+
+// ApplyAll is the policy that applies every proposal.
+func ApplyAll(domain.RenameResult) bool { return true }
+
+// conditionOperators are tried in order, so that ">=" is not read as ">".
+var conditionOperators = []string{">=", "<=", ">", "<"}
+
+// ParseCondition parses a condition on a proposal, such as "confidence>=0.8",
+// into the policy that applies exactly the proposals meeting it. The only
+// field so far is confidence, compared with >=, >, <= or <.
+func ParseCondition(expr string) (Policy, error) {
+	for _, op := range conditionOperators {
+		field, value, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		if field = strings.TrimSpace(field); field != "confidence" {
+			return nil, fmt.Errorf("invalid condition %q: unknown field %q (valid: confidence)", expr, field)
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %q is not a number", expr, strings.TrimSpace(value))
+		}
+		return func(r domain.RenameResult) bool {
+			switch op {
+			case ">=":
+				return r.Confidence >= threshold
+			case "<=":
+				return r.Confidence <= threshold
+			case ">":
+				return r.Confidence > threshold
+			default:
+				return r.Confidence < threshold
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid condition %q: expected e.g. confidence>=0.8", expr)
+}
@@ -0,0 +1,44 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestParseCondition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr    string
+		applies []float64 // confidences the policy applies
+		skips   []float64
+		wantErr bool
+	}{
+		{expr: "confidence>=0.8", applies: []float64{0.8, 1}, skips: []float64{0.79}},
+		{expr: " confidence > 0.8 ", applies: []float64{0.81}, skips: []float64{0.8}},
+		{expr: "confidence<=0.5", applies: []float64{0.5}, skips: []float64{0.51}},
+		{expr: "confidence<0.5", applies: []float64{0.49}, skips: []float64{0.5}},
+		{expr: "score>=0.8", wantErr: true},
+		{expr: "confidence>=high", wantErr: true},
+		{expr: "confidence", wantErr: true},
+	}
+	for _, tc := range tests {
+		policy, err := app.ParseCondition(tc.expr)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseCondition(%q) error = %v, wantErr %v", tc.expr, err, tc.wantErr)
+			continue
+		}
+		for _, c := range tc.applies {
+			if !policy(domain.RenameResult{Confidence: c}) {
+				t.Errorf("ParseCondition(%q) skips confidence %v", tc.expr, c)
+			}
+		}
+		for _, c := range tc.skips {
+			if policy(domain.RenameResult{Confidence: c}) {
+				t.Errorf("ParseCondition(%q) applies confidence %v", tc.expr, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,718 @@
+// Package app contains the rename application service. It orchestrates the domain
+// logic and talks to the outside world only through the interfaces in ports.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+	"github.com/maltehedderich/rename-ai/internal/ports"
+	"github.com/maltehedderich/rename-ai/internal/ratelimit"
+)
+
+// Reasons a file is skipped without being renamed.
+var (
+	// ErrLowConfidence marks proposals that Review skipped because the AI was
+	// less confident about them than the configured minimum.
+	ErrLowConfidence = errors.New("confidence too low")
+	// ErrNameMatches marks files that were not analyzed because their current
+	// name matches the skip pattern.
+	ErrNameMatches = errors.New("name matches the skip pattern")
+	// ErrNewCategory marks files the AI filed into a category that is not in
+	// the taxonomy, when creating that category was not approved.
+	ErrNewCategory = errors.New("new category was not approved")
+)
+
+// Service runs the rename flow: detect -> validate -> read -> GenerateName ->
+// Render -> ResolveCollision -> review -> rename.
+type Service struct {
+	fs      ports.FileSystem
+	ai      ports.AIProvider
+	ui      ports.UI
+	journal ports.Journal
+	cache   ports.Cache
+	editor  ports.Editor
+	limiter *ratelimit.Limiter
+
+	jobs          int
+	style         domain.Style
+	template      *domain.Template
+	translit      *domain.Transliterator
+	extPolicy     domain.ExtensionPolicy
+	collision     domain.CollisionStrategy
+	allowedTypes  []string
+	instructions  string
+	candidates    int
+	minConfidence float64
+	skipNames     *regexp.Regexp
+	organizeRoot  string
+	taxonomy      *domain.Taxonomy
+	dryRun        bool
+	exactNames    bool
+	policy        Policy
+}
+
+// Option configures optional behaviour of a Service.
+type Option func(*Service)
+
+// WithJournal records every applied rename in j.
+func WithJournal(j ports.Journal) Option {
+	return func(s *Service) { s.journal = j }
+}
+
+// WithCache answers repeated requests from c instead of the AI provider.
+func WithCache(c ports.Cache) Option {
+	return func(s *Service) { s.cache = c }
+}
+
+// WithEditor lets Edit open the proposals in e.
+func WithEditor(e ports.Editor) Option {
+	return func(s *Service) { s.editor = e }
+}
+
+// WithLimiter budgets AI requests with l.
+func WithLimiter(l *ratelimit.Limiter) Option {
+	return func(s *Service) { s.limiter = l }
+}
+
+// WithJobs sets how many files are analyzed concurrently (default 1).
+func WithJobs(n int) Option {
+	return func(s *Service) { s.jobs = max(n, 1) }
+}
+
+// WithStyle sets the naming style (default kebab).
+func WithStyle(style domain.Style) Option {
+	return func(s *Service) { s.style = style }
+}
+
+// WithTemplate sets the filename template (default domain.DefaultTemplate).
+func WithTemplate(t *domain.Template) Option {
+	return func(s *Service) { s.template = t }
+}
+
+// WithTransliterator sets how characters outside ASCII are treated in
+// generated and edited names (default domain.UnicodeTranslit).
+func WithTransliterator(t *domain.Transliterator) Option {
+	return func(s *Service) { s.translit = t }
+}
+
+// WithExtensionPolicy sets how the extension of a renamed file is chosen.
+func WithExtensionPolicy(p domain.ExtensionPolicy) Option {
+	return func(s *Service) { s.extPolicy = p }
+}
+
+// WithCollisionStrategy sets what happens when a proposed name is taken.
+func WithCollisionStrategy(c domain.CollisionStrategy) Option {
+	return func(s *Service) { s.collision = c }
+}
+
+// WithAllowedTypes restricts the supported MIME types further, e.g. "image/*".
+func WithAllowedTypes(patterns []string) Option {
+	return func(s *Service) { s.allowedTypes = patterns }
+}
+
+// WithInstructions appends user-supplied instructions to the prompt.
+func WithInstructions(instructions string) Option {
+	return func(s *Service) { s.instructions = instructions }
+}
+
+// WithCandidates asks the AI for n ranked candidates per file (default 1).
+// Review and ReviewEach let the user pick one of them.
+func WithCandidates(n int) Option {
+	return func(s *Service) { s.candidates = max(n, 1) }
+}
+
+// WithMinConfidence makes Review leave files untouched whose proposal the AI
+// is less confident about than min, from 0 to 1.
+func WithMinConfidence(min float64) Option {
+	return func(s *Service) { s.minConfidence = min }
+}
+
+// WithSkipNames leaves files whose current name matches re alone, without
+// asking the AI, e.g. files that already follow the naming scheme.
+func WithSkipNames(re *regexp.Regexp) Option {
+	return func(s *Service) { s.skipNames = re }
+}
+
+// WithTaxonomy moves every file into the folder of the category the AI
+// chooses from t, below root. Review asks before a category that is not in t
+// is created.
+func WithTaxonomy(root string, t *domain.Taxonomy) Option {
+	return func(s *Service) {
+		s.organizeRoot = root
+		s.taxonomy = t
+	}
+}
+
+// WithDryRun makes Review present the proposals without asking or renaming.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Service) { s.dryRun = dryRun }
+}
+
+// WithExactNames makes Apply fail a rename whose target was taken after
+// review instead of picking the next free name, e.g. to execute a plan exactly
+// as written.
+func WithExactNames() Option {
+	return func(s *Service) { s.exactNames = true }
+}
+
+// WithPolicy makes Review decide with p instead of asking for confirmation, so
+// the flow can run unattended.
+func WithPolicy(p Policy) Option {
+	return func(s *Service) { s.policy = p }
+}
+
+func NewService(fs ports.FileSystem, ai ports.AIProvider, ui ports.UI, opts ...Option) *Service {
+	tmpl, _ := domain.ParseTemplate(domain.DefaultTemplate)
+	translit, _ := domain.NewTransliterator(domain.UnicodeTranslit, nil)
+	s := &Service{
+		fs:         fs,
+		ai:         ai,
+		ui:         ui,
+		limiter:    ratelimit.New(0, 0),
+		jobs:       1,
+		candidates: 1,
+		style:      domain.StyleKebab,
+		template:   tmpl,
+		translit:   translit,
+		extPolicy:  domain.ExtensionPolicy{FixFromMime: true},
+		collision:  domain.CollisionCounter,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Rename runs the whole flow for a single file and returns its result. The
+// returned error is the file's error, or the error that aborted the flow.
+func (s *Service) Rename(ctx context.Context, req domain.RenameRequest) (domain.RenameResult, error) {
+	results, err := s.Run(ctx, []domain.RenameRequest{req})
+	if err != nil {
+		return results[0], err
+	}
+	return results[0], results[0].Err
+}
+
+// Run proposes names for all requests, presents them, asks for confirmation and
+// applies them. Results are returned in request order. An error is only returned
+// if the flow as a whole was aborted; per-file failures are reported in the results.
+func (s *Service) Run(ctx context.Context, reqs []domain.RenameRequest) ([]domain.RenameResult, error) {
+	results := s.Propose(ctx, reqs)
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	confirmed, err := s.Review(results)
+	if err != nil || !confirmed {
+		return results, err
+	}
+	s.Apply(results)
+	return results, nil
+}
+
+// Propose generates a name for every request on a pool of workers and then
+// resolves collisions in request order, so results are deterministic regardless
+// of completion order. Nothing on disk is changed. Once ctx is cancelled no new
+// files are started; their results carry the context error.
+func (s *Service) Propose(ctx context.Context, reqs []domain.RenameRequest) []domain.RenameResult {
+	results := make([]domain.RenameResult, len(reqs))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(s.jobs, len(reqs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if err := ctx.Err(); err != nil {
+					results[i] = failed(newResult(reqs[i]), err)
+					continue
+				}
+				results[i] = s.propose(ctx, reqs[i])
+			}
+		}()
+	}
+
+	for i := range reqs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	s.resolveCollisions(results)
+	return results
+}
+
+func newResult(req domain.RenameRequest) domain.RenameResult {
+	return domain.RenameResult{
+		OriginalPath: req.OriginalPath,
+		OriginalName: filepath.Base(req.OriginalPath),
+		MimeType:     req.MimeType,
+	}
+}
+
+func failed(r domain.RenameResult, err error) domain.RenameResult {
+	r.Status = domain.StatusFailed
+	r.Err = err
+	return r
+}
+
+// propose runs detect -> validate -> read -> GenerateName -> Render for one file.
+// Content and MIME type are taken from the request when provided.
+func (s *Service) propose(ctx context.Context, req domain.RenameRequest) domain.RenameResult {
+	r := newResult(req)
+	if s.skipNames != nil && s.skipNames.MatchString(r.OriginalName) {
+		r.Status = domain.StatusSkipped
+		r.Err = fmt.Errorf("%w %s", ErrNameMatches, s.skipNames)
+		return r
+	}
+
+	if r.MimeType == "" {
+		mimeType, err := s.fs.GetMimeType(req.OriginalPath)
+		if err != nil {
+			return failed(r, fmt.Errorf("failed to detect mime type: %w", err))
+		}
+		r.MimeType = mimeType
+	}
+	s.ui.PrintDetectedType(r.OriginalPath, r.MimeType)
+
+	if err := domain.IsAllowedMimeType(r.MimeType); err != nil {
+		return failed(r, fmt.Errorf("validation failed: %w", err))
+	}
+	if !domain.MatchesMimeType(r.MimeType, s.allowedTypes) {
+		return failed(r, fmt.Errorf("validation failed: file type %s is not allowed by the active profile", r.MimeType))
+	}
+
+	s.ui.PrintAnalyzing(r.OriginalPath)
+	content := req.Content
+	if content == nil {
+		var err error
+		if content, err = s.fs.ReadFile(req.OriginalPath); err != nil {
+			return failed(r, fmt.Errorf("failed to read file: %w", err))
+		}
+	}
+	r.ContentHash = domain.ContentHash(content)
+
+	ext := req.Extension
+	if ext == "" {
+		var fixed bool
+		if ext, fixed = s.extPolicy.Extension(req.OriginalPath, r.MimeType); fixed {
+			s.ui.Info(fmt.Sprintf("%s is %s: using %s", r.OriginalName, r.MimeType, ext))
+		}
+	}
+	if err := s.suggest(ctx, &r, content, ext, nil); err != nil {
+		return failed(r, err)
+	}
+	return r
+}
+
+// suggest asks for a name for content and stores it, with the reasoning and
+// the AI's field values, in r as a new proposal. If several candidates were
+// requested, the distinct ones are kept in r.Candidates, best first.
+func (s *Service) suggest(ctx context.Context, r *domain.RenameResult, content []byte, ext string, history []domain.Revision) error {
+	suggestion, err := s.generate(ctx, domain.NameRequest{
+		Content:   content,
+		MimeType:  r.MimeType,
+		Extension: ext,
+		Name:      r.OriginalName,
+		Template:  s.template.String(),
+		Fields:    s.fields(),
+
+		Categories:   s.categories(),
+		Instructions: s.instructions,
+		Candidates:   s.candidates,
+		History:      history,
+	})
+	if err != nil {
+		return err
+	}
+
+	best, err := s.candidate(suggestion, ext)
+	if err != nil {
+		return err
+	}
+	candidates := []domain.Candidate{best}
+	for _, alt := range suggestion.Alternatives {
+		c, err := s.candidate(alt, ext)
+		if err == nil && !slices.ContainsFunc(candidates, func(o domain.Candidate) bool { return o.Name == c.Name && o.Dir == c.Dir }) {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 1 {
+		candidates = nil
+	}
+
+	r.Candidates = candidates
+	r.Status = domain.StatusProposed
+	choose(r, best)
+	return nil
+}
+
+// fields returns the fields the AI has to provide: those of the template and,
+// when organizing, those of the taxonomy's folders.
+func (s *Service) fields() []string {
+	fields := s.template.Fields()
+	if s.taxonomy == nil {
+		return fields
+	}
+	for _, field := range s.taxonomy.Fields() {
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (s *Service) categories() []string {
+	if s.taxonomy == nil {
+		return nil
+	}
+	return s.taxonomy.Categories()
+}
+
+// candidate renders one suggestion into a filename and, when organizing, the
+// folder it is moved into.
+func (s *Service) candidate(suggestion domain.NameSuggestion, ext string) (domain.Candidate, error) {
+	fields, err := s.translit.ApplyFields(suggestion.Fields)
+	if err != nil {
+		return domain.Candidate{}, err
+	}
+	name, err := s.template.Render(fields, ext, s.style)
+	if err != nil {
+		return domain.Candidate{}, err
+	}
+	c := domain.Candidate{
+		Name:       name,
+		Reasoning:  suggestion.Reasoning,
+		Confidence: suggestion.Confidence,
+		Fields:     suggestion.Fields,
+	}
+	if s.taxonomy == nil {
+		return c, nil
+	}
+	dir, isNew, err := s.taxonomy.Render(fields, s.style)
+	if err != nil {
+		return domain.Candidate{}, err
+	}
+	c.Dir = filepath.Join(s.organizeRoot, filepath.FromSlash(dir))
+	if isNew {
+		c.NewCategory = dir
+	}
+	return c, nil
+}
+
+// choose makes c the proposal of r.
+func choose(r *domain.RenameResult, c domain.Candidate) {
+	r.ProposedName = c.Name
+	r.Taken = ""
+	r.TargetDir = c.Dir
+	r.NewCategory = c.NewCategory
+	r.Reasoning = c.Reasoning
+	r.Confidence = c.Confidence
+	r.Fields = c.Fields
+}
+
+// generate asks the AI provider for a suggestion, unless the cache has one.
+// Cache hits do not count against the rate limits. Requests with a history are
+// never cached: they depend on the user's hints.
+func (s *Service) generate(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	if len(req.History) > 0 {
+		return s.ask(ctx, req)
+	}
+	if s.cache != nil {
+		if suggestion, ok := s.cache.Get(req); ok {
+			return suggestion, nil
+		}
+	}
+
+	suggestion, err := s.ask(ctx, req)
+	if err != nil {
+		return domain.NameSuggestion{}, err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Put(req, suggestion); err != nil {
+			s.ui.Error(fmt.Sprintf("Could not cache the suggestion: %v", err))
+		}
+	}
+	return suggestion, nil
+}
+
+// ask sends the request to the AI provider within the rate limits.
+func (s *Service) ask(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	if err := s.limiter.Wait(ctx, ratelimit.EstimateTokens(req.Content, req.MimeType)); err != nil {
+		return domain.NameSuggestion{}, fmt.Errorf("rate limiter: %w", err)
+	}
+	suggestion, err := s.ai.GenerateName(ctx, req)
+	if err != nil {
+		return domain.NameSuggestion{}, fmt.Errorf("AI generation failed: %w", err)
+	}
+	return suggestion, nil
+}
+
+// Review presents the proposals and asks for confirmation, or consults the
+// policy if one is set. Proposals below the minimum confidence are skipped with
+// ErrLowConfidence first. Where the AI offered several candidates, the user
+// picks one, unless the run is a dry-run or decided by a policy. New categories
+// need approval, see approveCategories. It returns
+// false without asking in dry-run mode or when there is nothing to rename.
+// Proposals that will not be applied are marked as skipped.
+func (s *Service) Review(results []domain.RenameResult) (bool, error) {
+	s.skipLowConfidence(results)
+	if !s.dryRun && s.policy == nil {
+		if err := s.pickCandidates(results); err != nil {
+			skipProposed(results)
+			return false, err
+		}
+	}
+
+	if len(results) == 1 {
+		r := results[0]
+		switch {
+		case r.Err != nil && r.Status == domain.StatusSkipped:
+			s.ui.Info(fmt.Sprintf("Skipped: %v", r.Err))
+			return false, nil
+		case r.Err != nil:
+			s.ui.Error(r.Err.Error())
+			return false, nil
+		}
+		s.ui.PrintProposal(r)
+	} else {
+		s.ui.PrintReviewTable(results)
+	}
+
+	if s.dryRun {
+		s.ui.PrintDryRun()
+		skipProposed(results)
+		return false, nil
+	}
+	if err := s.approveCategories(results); err != nil {
+		skipProposed(results)
+		return false, err
+	}
+	if err := s.approveTakenNames(results); err != nil {
+		skipProposed(results)
+		return false, err
+	}
+	renamable := Count(results, domain.StatusProposed)
+	if renamable == 0 {
+		s.ui.Info("Nothing to rename.")
+		return false, nil
+	}
+	if s.policy != nil {
+		for i := range results {
+			if results[i].Status == domain.StatusProposed && !s.policy(results[i]) {
+				results[i].Status = domain.StatusSkipped
+				s.ui.Info(fmt.Sprintf("%s: skipped by policy", results[i].OriginalName))
+			}
+		}
+		return Count(results, domain.StatusProposed) > 0, nil
+	}
+
+	question := "Rename?"
+	if len(results) > 1 {
+		question = fmt.Sprintf("Rename %d files?", renamable)
+	}
+	confirm, err := s.ui.Confirm(question)
+	if err != nil {
+		skipProposed(results)
+		return false, fmt.Errorf("input error: %w", err)
+	}
+	if !confirm {
+		s.ui.PrintCancelled()
+		skipProposed(results)
+		return false, nil
+	}
+	return true, nil
+}
+
+// skipLowConfidence marks proposals the AI is not confident enough about as
+// skipped, so that they are reported but never applied.
+func (s *Service) skipLowConfidence(results []domain.RenameResult) {
+	if s.minConfidence <= 0 {
+		return
+	}
+	for i := range results {
+		r := &results[i]
+		if r.Status == domain.StatusProposed && r.Confidence < s.minConfidence {
+			r.Status = domain.StatusSkipped
+			r.Err = fmt.Errorf("%w: %.2f below %.2f", ErrLowConfidence, r.Confidence, s.minConfidence)
+		}
+	}
+}
+
+// pickCandidates lets the user choose among the candidates of every proposal
+// that has more than one. Declining all of them skips the file.
+func (s *Service) pickCandidates(results []domain.RenameResult) error {
+	picked := false
+	for i := range results {
+		r := &results[i]
+		if r.Status != domain.StatusProposed || len(r.Candidates) < 2 {
+			continue
+		}
+		if err := s.pick(r); err != nil {
+			return err
+		}
+		picked = true
+	}
+	if picked {
+		s.resolveCollisions(results)
+	}
+	return nil
+}
+
+// pick asks the user which candidate r should use.
+func (s *Service) pick(r *domain.RenameResult) error {
+	choice, err := s.ui.PickCandidate(r.OriginalName, r.Candidates)
+	if err != nil {
+		return fmt.Errorf("input error: %w", err)
+	}
+	if choice < 0 || choice >= len(r.Candidates) {
+		r.Status = domain.StatusSkipped
+		return nil
+	}
+	choose(r, r.Candidates[choice])
+	return nil
+}
+
+// approveCategories asks once per new category whether it may be created for
+// the files the AI filed into it. Under a policy nobody can be asked, so new
+// categories are never created. Files whose category is declined are skipped
+// with ErrNewCategory.
+func (s *Service) approveCategories(results []domain.RenameResult) error {
+	var categories []string
+	files := make(map[string][]int)
+	for i, r := range results {
+		if r.Status != domain.StatusProposed || r.NewCategory == "" {
+			continue
+		}
+		if _, ok := files[r.NewCategory]; !ok {
+			categories = append(categories, r.NewCategory)
+		}
+		files[r.NewCategory] = append(files[r.NewCategory], i)
+	}
+
+	for _, category := range categories {
+		indices := files[category]
+		approved := false
+		if s.policy == nil {
+			question := fmt.Sprintf("Create new category %q for %s?", category, results[indices[0]].OriginalName)
+			if len(indices) > 1 {
+				question = fmt.Sprintf("Create new category %q for %d files?", category, len(indices))
+			}
+			var err error
+			if approved, err = s.ui.Confirm(question); err != nil {
+				return fmt.Errorf("input error: %w", err)
+			}
+		}
+		if approved {
+			s.ui.Info(fmt.Sprintf("Add %q to the taxonomy to file into it without asking.", category))
+			continue
+		}
+		for _, i := range indices {
+			results[i].Status = domain.StatusSkipped
+			results[i].Err = fmt.Errorf("%w: %s", ErrNewCategory, category)
+			s.ui.Info(fmt.Sprintf("%s: skipped, %v", results[i].OriginalName, results[i].Err))
+		}
+	}
+	return nil
+}
+
+func skipProposed(results []domain.RenameResult) {
+	for i := range results {
+		if results[i].Status == domain.StatusProposed {
+			results[i].Status = domain.StatusSkipped
+		}
+	}
+}
+
+// Apply renames every proposed result in order and records it in the journal.
+func (s *Service) Apply(results []domain.RenameResult) {
+	for i := range results {
+		r := &results[i]
+		if r.Status != domain.StatusProposed {
+			continue
+		}
+		if r.TargetDir != "" {
+			if err := s.fs.MkdirAll(r.TargetDir); err != nil {
+				*r = failed(*r, err)
+				s.ui.Error(fmt.Sprintf("Rename failed: %v", err))
+				continue
+			}
+		}
+		if err := s.rename(results, i); err != nil {
+			*r = failed(*r, err)
+			s.ui.Error(fmt.Sprintf("Rename failed: %v", err))
+			continue
+		}
+		r.Status = domain.StatusRenamed
+		s.ui.PrintRenamed(*r)
+
+		if s.journal == nil {
+			continue
+		}
+		if err := s.journal.Record(*r); err != nil {
+			s.ui.Error(fmt.Sprintf("Renamed %s but could not record it for undo: %v", r.OriginalName, err))
+		}
+	}
+}
+
+// maxRenameAttempts bounds how often Apply picks another name for a file whose
+// target keeps being taken by other processes.
+const maxRenameAttempts = 10
+
+// rename moves results[i] to its new path, replacing the file there only if
+// the collision strategy decided so and the file is unchanged, see replace.
+// Otherwise the filesystem never replaces an existing file, so if another
+// process took the target after collisions were resolved, the rename fails
+// with os.ErrExist; with the counter or hash strategy the next free name, not
+// claimed by a later result, is then chosen and the rename retried. With exact
+// names or any other strategy the error is returned instead, as the file now
+// in the way was never reviewed.
+func (s *Service) rename(results []domain.RenameResult, i int) error {
+	r := &results[i]
+	if r.Replaces {
+		return s.replace(*r)
+	}
+	retry := !s.exactNames && (s.collision == domain.CollisionCounter || s.collision == domain.CollisionHash)
+	wanted := r.ProposedName
+	taken := make(map[string]struct{})
+	for attempt := 1; ; attempt++ {
+		err := s.fs.Rename(r.OriginalPath, r.NewPath())
+		if err == nil || !errors.Is(err, os.ErrExist) || !retry || attempt == maxRenameAttempts {
+			return err
+		}
+		taken[r.NewPath()] = struct{}{}
+		r.ProposedName = domain.ResolveCollision(wanted, func(name string) bool {
+			path := filepath.Join(r.Dir(), name)
+			if _, ok := taken[path]; ok {
+				return true
+			}
+			for _, later := range results[i+1:] {
+				if later.Status == domain.StatusProposed && later.NewPath() == path {
+					return true
+				}
+			}
+			return s.fs.Exists(path)
+		})
+		s.ui.PrintCollision(*r, wanted)
+	}
+}
+
+// Count returns how many results have the given status.
+func Count(results []domain.RenameResult, status domain.RenameStatus) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,929 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/adapters/cache"
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// fakeFS is an in-memory ports.FileSystem.
+type fakeFS struct {
+	mu     sync.Mutex
+	files  map[string]string    // path -> content
+	mtimes map[string]time.Time // path -> modification time, zero if unset
+	dirs   []string             // directories created, in order
+}
+
+func newFakeFS(files ...string) *fakeFS {
+	fs := &fakeFS{files: make(map[string]string), mtimes: make(map[string]time.Time)}
+	for _, f := range files {
+		fs.files[f] = "content of " + f
+	}
+	return fs
+}
+
+func (f *fakeFS) ReadFile(path string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.files[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(content), nil
+}
+
+func (f *fakeFS) Rename(oldPath, newPath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.files[oldPath]; !ok {
+		return fmt.Errorf("rename %s: not found", oldPath)
+	}
+	if _, ok := f.files[newPath]; ok {
+		return fmt.Errorf("rename %s: %w", newPath, os.ErrExist)
+	}
+	f.files[newPath] = f.files[oldPath]
+	f.mtimes[newPath] = f.mtimes[oldPath]
+	delete(f.files, oldPath)
+	return nil
+}
+
+func (f *fakeFS) Replace(oldPath, newPath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.files[oldPath]; !ok {
+		return fmt.Errorf("replace %s: not found", oldPath)
+	}
+	f.files[newPath] = f.files[oldPath]
+	f.mtimes[newPath] = f.mtimes[oldPath]
+	delete(f.files, oldPath)
+	return nil
+}
+
+func (f *fakeFS) HashFile(path string) (string, error) {
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return domain.ContentHash(content), nil
+}
+
+func (f *fakeFS) ModTime(path string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mtimes[path], nil
+}
+
+func (f *fakeFS) Exists(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.files[path]
+	return ok
+}
+
+func (f *fakeFS) MkdirAll(dir string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dirs = append(f.dirs, dir)
+	return nil
+}
+
+// GetMimeType detects PNG images by their signature and everything else by
+// the extension.
+func (f *fakeFS) GetMimeType(path string) (string, error) {
+	f.mu.Lock()
+	content := f.files[path]
+	f.mu.Unlock()
+	if strings.HasPrefix(content, "\x89PNG") {
+		return "image/png", nil
+	}
+	switch filepath.Ext(path) {
+	case ".txt":
+		return "text/plain; charset=utf-8", nil
+	case ".pdf":
+		return "application/pdf", nil
+	default:
+		return "application/octet-stream", nil
+	}
+}
+
+// fakeAI returns the subject configured for each file's content, extended by
+// the hints of any earlier revisions. Alternatives are the subject with
+// " Alt 1", " Alt 2", ... appended, each 0.1 less confident than the last.
+type fakeAI struct {
+	subjects   map[string]string  // content -> subject
+	confidence map[string]float64 // content -> confidence, default 0.9
+	categories map[string]string  // content -> category, when organizing
+	delay      func(content string) time.Duration
+	calls      atomic.Int32
+}
+
+func (a *fakeAI) GenerateName(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	a.calls.Add(1)
+	content := string(req.Content)
+	if a.delay != nil {
+		time.Sleep(a.delay(content))
+	}
+	subject, ok := a.subjects[content]
+	if !ok {
+		return domain.NameSuggestion{}, errors.New("model refused")
+	}
+	// Follow the user's hints, e.g. "Invoice" with hint "ACME" becomes "Invoice ACME".
+	for _, r := range req.History {
+		subject += " " + r.Hint
+	}
+	confidence, ok := a.confidence[content]
+	if !ok {
+		confidence = 0.9
+	}
+	suggestion := domain.NameSuggestion{
+		Fields:     map[string]string{"date": "2024-01-01", "subject": subject},
+		Reasoning:  "because " + subject,
+		Confidence: confidence,
+	}
+	if category, ok := a.categories[content]; ok {
+		suggestion.Fields[domain.FieldCategory] = category
+	}
+	for i := 1; i < req.Candidates; i++ {
+		alt := fmt.Sprintf("%s Alt %d", subject, i)
+		suggestion.Alternatives = append(suggestion.Alternatives, domain.NameSuggestion{
+			Fields:     map[string]string{"date": "2024-01-01", "subject": alt},
+			Reasoning:  "because " + alt,
+			Confidence: confidence - 0.1*float64(i),
+		})
+	}
+	return suggestion, nil
+}
+
+// fakeUI records what is shown and answers confirmations and questions.
+type fakeUI struct {
+	mu         sync.Mutex
+	answer     bool
+	answers    []string // answers to Ask, in order
+	picks      []int    // answers to PickCandidate, in order
+	asked      []string
+	errors     []string
+	success    []string
+	proposals  []string
+	collisions []string
+}
+
+func (u *fakeUI) Info(string)                            {}
+func (u *fakeUI) PrintDetectedType(string, string)       {}
+func (u *fakeUI) PrintAnalyzing(string)                  {}
+func (u *fakeUI) PrintReviewTable([]domain.RenameResult) {}
+func (u *fakeUI) PrintDryRun()                           {}
+func (u *fakeUI) PrintCancelled()                        {}
+func (u *fakeUI) PrintRenamed(r domain.RenameResult)     { u.success = append(u.success, r.ProposedName) }
+func (u *fakeUI) Confirm(q string) (bool, error)         { u.asked = append(u.asked, q); return u.answer, nil }
+func (u *fakeUI) PrintProposal(r domain.RenameResult) {
+	u.proposals = append(u.proposals, r.ProposedName)
+}
+
+func (u *fakeUI) PrintCollision(r domain.RenameResult, taken string) {
+	u.collisions = append(u.collisions, taken+" -> "+r.ProposedName)
+}
+
+func (u *fakeUI) Ask(q string) (string, error) {
+	u.asked = append(u.asked, q)
+	if len(u.answers) == 0 {
+		return "", errors.New("no more input")
+	}
+	answer := u.answers[0]
+	u.answers = u.answers[1:]
+	return answer, nil
+}
+
+func (u *fakeUI) PickCandidate(_ string, candidates []domain.Candidate) (int, error) {
+	if len(u.picks) == 0 {
+		return -1, errors.New("no more input")
+	}
+	pick := u.picks[0]
+	u.picks = u.picks[1:]
+	return pick, nil
+}
+
+func (u *fakeUI) Error(msg string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.errors = append(u.errors, msg)
+}
+
+type fakeJournal struct {
+	recorded []domain.RenameResult
+}
+
+func (j *fakeJournal) Record(r domain.RenameResult) error {
+	j.recorded = append(j.recorded, r)
+	return nil
+}
+
+func requests(paths ...string) []domain.RenameRequest {
+	reqs := make([]domain.RenameRequest, len(paths))
+	for i, p := range paths {
+		reqs[i] = domain.RenameRequest{OriginalPath: p}
+	}
+	return reqs
+}
+
+func TestService_Rename(t *testing.T) {
+	t.Parallel()
+
+	fs := newFakeFS("docs/scan.pdf")
+	ai := &fakeAI{subjects: map[string]string{"content of docs/scan.pdf": "Budget Report"}}
+	ui := &fakeUI{answer: true}
+	jrnl := &fakeJournal{}
+	svc := app.NewService(fs, ai, ui, app.WithJournal(jrnl))
+
+	result, err := svc.Rename(context.Background(), domain.RenameRequest{OriginalPath: "docs/scan.pdf"})
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if result.Status != domain.StatusRenamed || result.ProposedName != "2024-01-01_budget-report.pdf" {
+		t.Errorf("Rename() = %+v", result)
+	}
+	if !fs.Exists("docs/2024-01-01_budget-report.pdf") || fs.Exists("docs/scan.pdf") {
+		t.Errorf("file not renamed on disk: %v", fs.files)
+	}
+	if len(jrnl.recorded) != 1 || jrnl.recorded[0].ContentHash != domain.ContentHash([]byte("content of docs/scan.pdf")) {
+		t.Errorf("journal = %+v", jrnl.recorded)
+	}
+	if len(ui.asked) != 1 || ui.asked[0] != "Rename?" {
+		t.Errorf("asked = %v", ui.asked)
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		files      []string
+		subjects   map[string]string
+		opts       []app.Option
+		answer     bool
+		wantStatus []domain.RenameStatus
+		wantNames  []string
+		wantFiles  []string
+	}{
+		{
+			name:  "batch with collisions inside the run and on disk",
+			files: []string{"a.pdf", "b.pdf", "c.pdf", "2024-01-01_invoice.pdf"},
+			subjects: map[string]string{
+				"content of a.pdf":                  "Invoice",
+				"content of b.pdf":                  "Invoice",
+				"content of c.pdf":                  "Letter",
+				"content of 2024-01-01_invoice.pdf": "Invoice",
+			},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusRenamed, domain.StatusRenamed, domain.StatusRenamed, domain.StatusUnchanged},
+			wantNames:  []string{"2024-01-01_invoice-1.pdf", "2024-01-01_invoice-2.pdf", "2024-01-01_letter.pdf", "2024-01-01_invoice.pdf"},
+			wantFiles:  []string{"2024-01-01_invoice-1.pdf", "2024-01-01_invoice-2.pdf", "2024-01-01_letter.pdf", "2024-01-01_invoice.pdf"},
+		},
+		{
+			name:       "failures do not stop the batch",
+			files:      []string{"a.pdf", "b.bin", "c.txt"},
+			subjects:   map[string]string{"content of a.pdf": "Report"},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusRenamed, domain.StatusFailed, domain.StatusFailed},
+			wantFiles:  []string{"2024-01-01_report.pdf", "b.bin", "c.txt"},
+		},
+		{
+			name:       "declined",
+			files:      []string{"a.pdf"},
+			subjects:   map[string]string{"content of a.pdf": "Report"},
+			answer:     false,
+			wantStatus: []domain.RenameStatus{domain.StatusSkipped},
+			wantFiles:  []string{"a.pdf"},
+		},
+		{
+			name:       "dry run",
+			files:      []string{"a.pdf"},
+			subjects:   map[string]string{"content of a.pdf": "Report"},
+			opts:       []app.Option{app.WithDryRun(true)},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusSkipped},
+			wantFiles:  []string{"a.pdf"},
+		},
+		{
+			name:     "policy instead of confirmation",
+			files:    []string{"a.pdf", "b.pdf"},
+			subjects: map[string]string{"content of a.pdf": "Report", "content of b.pdf": "Letter"},
+			opts: []app.Option{app.WithPolicy(func(r domain.RenameResult) bool {
+				return r.OriginalName == "a.pdf"
+			})},
+			answer:     false,
+			wantStatus: []domain.RenameStatus{domain.StatusRenamed, domain.StatusSkipped},
+			wantFiles:  []string{"2024-01-01_report.pdf", "b.pdf"},
+		},
+		{
+			name:       "skip names matching a pattern",
+			files:      []string{"2023-05-01_memo.txt", "scan.txt"},
+			subjects:   map[string]string{"content of scan.txt": "Notes"},
+			opts:       []app.Option{app.WithSkipNames(regexp.MustCompile(`^\d{4}-\d{2}-\d{2}_`))},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusSkipped, domain.StatusRenamed},
+			wantFiles:  []string{"2023-05-01_memo.txt", "2024-01-01_notes.txt"},
+		},
+		{
+			name:       "style and template",
+			files:      []string{"a.txt"},
+			subjects:   map[string]string{"content of a.txt": "meeting notes"},
+			opts:       []app.Option{app.WithStyle(domain.StylePascal), app.WithTemplate(mustTemplate(t, "{subject}-{date:2006}{ext}"))},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusRenamed},
+			wantNames:  []string{"MeetingNotes-2024.txt"},
+			wantFiles:  []string{"MeetingNotes-2024.txt"},
+		},
+		{
+			name:       "allowed types",
+			files:      []string{"a.pdf", "b.txt"},
+			subjects:   map[string]string{"content of a.pdf": "Report", "content of b.txt": "Notes"},
+			opts:       []app.Option{app.WithAllowedTypes([]string{"text/*"})},
+			answer:     true,
+			wantStatus: []domain.RenameStatus{domain.StatusFailed, domain.StatusRenamed},
+			wantFiles:  []string{"a.pdf", "2024-01-01_notes.txt"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFakeFS(tc.files...)
+			svc := app.NewService(fs, &fakeAI{subjects: tc.subjects}, &fakeUI{answer: tc.answer}, tc.opts...)
+
+			results, err := svc.Run(context.Background(), requests(tc.files...))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for i, r := range results {
+				if r.OriginalPath != tc.files[i] {
+					t.Errorf("results[%d] is for %s, want %s", i, r.OriginalPath, tc.files[i])
+				}
+				if r.Status != tc.wantStatus[i] {
+					t.Errorf("results[%d].Status = %s, want %s (err: %v)", i, r.Status, tc.wantStatus[i], r.Err)
+				}
+				if tc.wantNames != nil && r.ProposedName != tc.wantNames[i] {
+					t.Errorf("results[%d].ProposedName = %s, want %s", i, r.ProposedName, tc.wantNames[i])
+				}
+			}
+			for _, f := range tc.wantFiles {
+				if !fs.Exists(f) {
+					t.Errorf("expected %s on disk, have %v", f, fs.files)
+				}
+			}
+		})
+	}
+}
+
+func TestService_ProposePreservesOrderAndBoundsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	files := make([]string, 20)
+	subjects := make(map[string]string)
+	delays := make(map[string]time.Duration)
+	for i := range files {
+		files[i] = fmt.Sprintf("file-%02d.txt", i)
+		content := "content of " + files[i]
+		subjects[content] = fmt.Sprintf("Subject %02d", i)
+		// Later files finish first to make ordering bugs visible.
+		delays[content] = time.Duration(len(files)-i) * time.Millisecond
+	}
+
+	var running, peak atomic.Int32
+	ai := &fakeAI{subjects: subjects, delay: func(content string) time.Duration {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		return delays[content]
+	}}
+	svc := app.NewService(newFakeFS(files...), &countingAI{fakeAI: ai, running: &running}, &fakeUI{}, app.WithJobs(4))
+
+	results := svc.Propose(context.Background(), requests(files...))
+	if got := peak.Load(); got > 4 {
+		t.Errorf("peak concurrency = %d, want at most 4", got)
+	}
+	for i, r := range results {
+		want := fmt.Sprintf("2024-01-01_subject-%02d.txt", i)
+		if r.ProposedName != want {
+			t.Errorf("results[%d].ProposedName = %s, want %s", i, r.ProposedName, want)
+		}
+	}
+}
+
+// countingAI decrements the running counter once a fakeAI call returns.
+type countingAI struct {
+	*fakeAI
+	running *atomic.Int32
+}
+
+func (c *countingAI) GenerateName(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error) {
+	defer c.running.Add(-1)
+	return c.fakeAI.GenerateName(ctx, req)
+}
+
+func TestService_ProposeCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	files := []string{"a.txt", "b.txt", "c.txt"}
+	ai := &fakeAI{
+		subjects: map[string]string{"content of a.txt": "A", "content of b.txt": "B", "content of c.txt": "C"},
+		delay:    func(string) time.Duration { cancel(); return 0 },
+	}
+	svc := app.NewService(newFakeFS(files...), ai, &fakeUI{answer: true})
+
+	results, err := svc.Run(ctx, requests(files...))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if results[0].Status != domain.StatusProposed {
+		t.Errorf("first file should complete, got %+v", results[0])
+	}
+	for _, r := range results[1:] {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: error = %v, want %v", r.OriginalPath, r.Err, context.Canceled)
+		}
+	}
+	if got := ai.calls.Load(); got != 1 {
+		t.Errorf("AI called %d times after cancellation, want 1", got)
+	}
+}
+
+func TestService_UsesRequestContent(t *testing.T) {
+	t.Parallel()
+
+	ai := &fakeAI{subjects: map[string]string{"in memory": "Memo"}}
+	svc := app.NewService(newFakeFS(), ai, &fakeUI{}, app.WithDryRun(true))
+
+	result, err := svc.Rename(context.Background(), domain.RenameRequest{
+		OriginalPath: "virtual/note",
+		Content:      []byte("in memory"),
+		MimeType:     "text/plain",
+		Extension:    ".md",
+	})
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if result.ProposedName != "2024-01-01_memo.md" || !strings.HasPrefix(result.Reasoning, "because") {
+		t.Errorf("Rename() = %+v", result)
+	}
+}
+
+func TestService_Cache(t *testing.T) {
+	t.Parallel()
+
+	ai := &fakeAI{subjects: map[string]string{"content of a.txt": "Memo"}}
+	c := cache.New(t.TempDir(), "test", cache.DefaultTTL)
+	req := domain.RenameRequest{OriginalPath: "a.txt"}
+
+	for i := 0; i < 2; i++ {
+		svc := app.NewService(newFakeFS("a.txt"), ai, &fakeUI{}, app.WithCache(c), app.WithDryRun(true))
+		result, err := svc.Rename(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Rename() error = %v", err)
+		}
+		if result.ProposedName != "2024-01-01_memo.txt" || result.Reasoning != "because Memo" {
+			t.Errorf("run %d: Rename() = %+v", i+1, result)
+		}
+	}
+	if got := ai.calls.Load(); got != 1 {
+		t.Errorf("AI called %d times, want 1", got)
+	}
+
+	// A different template is a different request.
+	svc := app.NewService(newFakeFS("a.txt"), ai, &fakeUI{}, app.WithCache(c), app.WithDryRun(true),
+		app.WithTemplate(mustTemplate(t, "{subject}{ext}")))
+	if _, err := svc.Rename(context.Background(), req); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if got := ai.calls.Load(); got != 2 {
+		t.Errorf("AI called %d times, want 2", got)
+	}
+}
+
+func mustTemplate(t *testing.T, s string) *domain.Template {
+	t.Helper()
+	tmpl, err := domain.ParseTemplate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
+func TestService_Candidates(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf", "c.pdf"}
+	subjects := map[string]string{
+		"content of a.pdf": "Invoice",
+		"content of b.pdf": "Invoice",
+		"content of c.pdf": "Letter",
+	}
+	ui := &fakeUI{answer: true, picks: []int{1, 0, -1}}
+	svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, ui, app.WithCandidates(3))
+
+	results, err := svc.Run(context.Background(), requests(files...))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := results[0].Candidates; len(got) != 3 || got[0].Name != "2024-01-01_invoice.pdf" || got[2].Name != "2024-01-01_invoice-alt-2.pdf" {
+		t.Errorf("candidates = %+v", got)
+	}
+
+	wantStatus := []domain.RenameStatus{domain.StatusRenamed, domain.StatusRenamed, domain.StatusSkipped}
+	wantNames := []string{"2024-01-01_invoice-alt-1.pdf", "2024-01-01_invoice.pdf", "2024-01-01_letter.pdf"}
+	for i, r := range results {
+		if r.Status != wantStatus[i] || r.ProposedName != wantNames[i] {
+			t.Errorf("%s: %s %q, want %s %q", r.OriginalName, r.Status, r.ProposedName, wantStatus[i], wantNames[i])
+		}
+	}
+	if results[0].Confidence != 0.8 || results[0].Reasoning != "because Invoice Alt 1" {
+		t.Errorf("picked candidate = %v %q", results[0].Confidence, results[0].Reasoning)
+	}
+}
+
+func TestService_MinConfidence(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf"}
+	ai := &fakeAI{
+		subjects:   map[string]string{"content of a.pdf": "Invoice", "content of b.pdf": "Letter"},
+		confidence: map[string]float64{"content of b.pdf": 0.4},
+	}
+
+	for _, opt := range []app.Option{app.WithPolicy(app.ApplyAll), app.WithCandidates(2)} {
+		fs := newFakeFS(files...)
+		svc := app.NewService(fs, ai, &fakeUI{answer: true, picks: []int{0}}, opt, app.WithMinConfidence(0.6))
+
+		results, err := svc.Run(context.Background(), requests(files...))
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if results[0].Status != domain.StatusRenamed {
+			t.Errorf("a.pdf: status = %s, want renamed", results[0].Status)
+		}
+		if results[1].Status != domain.StatusSkipped || !errors.Is(results[1].Err, app.ErrLowConfidence) || !fs.Exists("b.pdf") {
+			t.Errorf("b.pdf: %s %v, want skipped for low confidence", results[1].Status, results[1].Err)
+		}
+	}
+}
+
+func TestService_ReportsCollisions(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf", "2024-01-01_invoice.pdf"}
+	subjects := map[string]string{
+		"content of a.pdf":                  "Invoice",
+		"content of b.pdf":                  "Letter",
+		"content of 2024-01-01_invoice.pdf": "Letter",
+	}
+	ui := &fakeUI{}
+	svc := app.NewService(newFakeFS(files...), &fakeAI{subjects: subjects}, ui)
+	svc.Propose(context.Background(), requests(files...))
+
+	want := []string{"2024-01-01_invoice.pdf -> 2024-01-01_invoice-1.pdf", "2024-01-01_letter.pdf -> 2024-01-01_letter-1.pdf"}
+	if strings.Join(ui.collisions, "\n") != strings.Join(want, "\n") {
+		t.Errorf("collisions = %q, want %q", ui.collisions, want)
+	}
+}
+
+func TestService_CollisionStrategies(t *testing.T) {
+	t.Parallel()
+
+	const target = "in/2024-01-01_invoice.pdf"
+	hashed := "in/2024-01-01_invoice-" + domain.ContentHash([]byte("new"))[:8] + ".pdf"
+	later := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		strategy domain.CollisionStrategy
+		decline  bool
+		files    map[string]string // path -> content; target exists if listed
+		newer    string            // file modified later than the others
+		want     map[string]string // files afterwards
+		wantAsk  string
+	}{
+		{
+			name:     "counter",
+			strategy: domain.CollisionCounter,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", "in/2024-01-01_invoice-1.pdf": "new"},
+		},
+		{
+			name:     "hash",
+			strategy: domain.CollisionHash,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", hashed: "new"},
+		},
+		{
+			name:     "skip",
+			strategy: domain.CollisionSkip,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", "in/a.pdf": "new"},
+		},
+		{
+			name:     "ask accepted",
+			strategy: domain.CollisionAsk,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", "in/2024-01-01_invoice-1.pdf": "new"},
+			wantAsk:  "2024-01-01_invoice.pdf is taken. Rename a.pdf to 2024-01-01_invoice-1.pdf instead?",
+		},
+		{
+			name:     "ask declined",
+			strategy: domain.CollisionAsk,
+			decline:  true,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", "in/a.pdf": "new"},
+		},
+		{
+			name:     "dedupe identical",
+			strategy: domain.CollisionDedupe,
+			files:    map[string]string{"in/a.pdf": "old", target: "old"},
+			want:     map[string]string{target: "old"},
+		},
+		{
+			name:     "dedupe different",
+			strategy: domain.CollisionDedupe,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			want:     map[string]string{target: "old", "in/2024-01-01_invoice-1.pdf": "new"},
+		},
+		{
+			name:     "keep newer replaces",
+			strategy: domain.CollisionKeepNewer,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			newer:    "in/a.pdf",
+			want:     map[string]string{target: "new"},
+		},
+		{
+			name:     "keep newer keeps",
+			strategy: domain.CollisionKeepNewer,
+			files:    map[string]string{"in/a.pdf": "new", target: "old"},
+			newer:    target,
+			want:     map[string]string{target: "old", "in/a.pdf": "new"},
+		},
+		{
+			name:     "counter in batch",
+			strategy: domain.CollisionCounter,
+			files:    map[string]string{"in/a.pdf": "new", "in/b.pdf": "other"},
+			want:     map[string]string{target: "new", "in/2024-01-01_invoice-1.pdf": "other"},
+		},
+		{
+			name:     "dedupe in batch",
+			strategy: domain.CollisionDedupe,
+			files:    map[string]string{"in/a.pdf": "new", "in/b.pdf": "new"},
+			want:     map[string]string{target: "new"},
+		},
+		{
+			name:     "keep newer in batch",
+			strategy: domain.CollisionKeepNewer,
+			files:    map[string]string{"in/a.pdf": "new", "in/b.pdf": "other"},
+			newer:    "in/b.pdf",
+			want:     map[string]string{target: "other", "in/a.pdf": "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFakeFS()
+			subjects := make(map[string]string)
+			for path, content := range tt.files {
+				fs.files[path] = content
+				subjects[content] = "Invoice"
+			}
+			if tt.newer != "" {
+				fs.mtimes[tt.newer] = later
+			}
+			ui := &fakeUI{answer: !tt.decline}
+			svc := app.NewService(fs, &fakeAI{subjects: subjects}, ui, app.WithCollisionStrategy(tt.strategy))
+
+			var paths []string
+			for _, path := range []string{"in/a.pdf", "in/b.pdf"} {
+				if _, ok := tt.files[path]; ok {
+					paths = append(paths, path)
+				}
+			}
+			if _, err := svc.Run(context.Background(), requests(paths...)); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if fmt.Sprint(fs.files) != fmt.Sprint(tt.want) {
+				t.Errorf("files = %v, want %v", fs.files, tt.want)
+			}
+			if tt.wantAsk != "" && (len(ui.asked) == 0 || ui.asked[0] != tt.wantAsk) {
+				t.Errorf("asked = %q, want %q first", ui.asked, tt.wantAsk)
+			}
+		})
+	}
+}
+
+func TestService_AskAfterReview(t *testing.T) {
+	t.Parallel()
+
+	const target = "in/2024-01-01_invoice.pdf"
+	for _, policy := range []bool{false, true} {
+		fs := newFakeFS()
+		fs.files["in/a.pdf"] = "new"
+		fs.files[target] = "old"
+		ui := &fakeUI{answer: true}
+		opts := []app.Option{app.WithCollisionStrategy(domain.CollisionAsk)}
+		if policy {
+			opts = append(opts, app.WithPolicy(app.ApplyAll))
+		}
+		svc := app.NewService(fs, &fakeAI{subjects: map[string]string{"new": "Invoice"}}, ui, opts...)
+
+		results := svc.Propose(context.Background(), requests("in/a.pdf"))
+		if len(ui.asked) != 0 || results[0].Taken != "2024-01-01_invoice.pdf" || results[0].ProposedName != "2024-01-01_invoice-1.pdf" {
+			t.Fatalf("Propose() = %+v, asked %q; want the free name awaiting approval", results[0], ui.asked)
+		}
+		if _, err := svc.Review(results); err != nil {
+			t.Fatalf("Review() error = %v", err)
+		}
+		if policy && (results[0].Status != domain.StatusSkipped || !errors.Is(results[0].Err, app.ErrTargetTaken)) {
+			t.Errorf("policy: Review() = %v, %v; want skipped as taken", results[0].Status, results[0].Err)
+		}
+		if !policy && (results[0].Status != domain.StatusProposed || results[0].Taken != "") {
+			t.Errorf("Review() = %+v, want the free name approved", results[0])
+		}
+	}
+}
+
+func TestService_ApplyReplacedFileChanged(t *testing.T) {
+	t.Parallel()
+
+	const target = "in/2024-01-01_invoice.pdf"
+	fs := newFakeFS()
+	fs.files["in/a.pdf"] = "same"
+	fs.files[target] = "same"
+	svc := app.NewService(fs, &fakeAI{subjects: map[string]string{"same": "Invoice"}}, &fakeUI{answer: true}, app.WithCollisionStrategy(domain.CollisionDedupe))
+
+	results := svc.Propose(context.Background(), requests("in/a.pdf"))
+	if !results[0].Replaces {
+		t.Fatalf("Propose() = %+v, want the identical file replaced", results[0])
+	}
+	fs.files[target] = "changed"
+	svc.Apply(results)
+
+	if results[0].Status != domain.StatusFailed || !errors.Is(results[0].Err, app.ErrReplacedChanged) {
+		t.Errorf("Apply() = %v, %v; want %v", results[0].Status, results[0].Err, app.ErrReplacedChanged)
+	}
+	if fs.files[target] != "changed" || fs.files["in/a.pdf"] != "same" {
+		t.Errorf("files = %v, want both untouched", fs.files)
+	}
+}
+
+func TestService_Organize(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"in/a.pdf", "in/b.pdf", "in/c.pdf", "archive/Letters/2024-01-01_letter.pdf"}
+	ai := &fakeAI{
+		subjects:   map[string]string{"content of in/a.pdf": "Invoice", "content of in/b.pdf": "Letter", "content of in/c.pdf": "Receipt"},
+		categories: map[string]string{"content of in/a.pdf": "invoices", "content of in/b.pdf": "Letters", "content of in/c.pdf": "Receipts"},
+	}
+	taxonomy, err := domain.ParseTaxonomy([]string{"Invoices/{date:2006}", "Letters"})
+	if err != nil {
+		t.Fatalf("ParseTaxonomy() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []app.Option
+		wantC    domain.RenameStatus
+		wantAsk  []string
+		wantDirs []string
+	}{
+		{
+			name:     "new category approved",
+			wantC:    domain.StatusRenamed,
+			wantAsk:  []string{`Create new category "receipts" for c.pdf?`, "Rename 3 files?"},
+			wantDirs: []string{"archive/Invoices/2024", "archive/Letters", "archive/receipts"},
+		},
+		{
+			name:     "policy never creates categories",
+			opts:     []app.Option{app.WithPolicy(app.ApplyAll)},
+			wantC:    domain.StatusSkipped,
+			wantDirs: []string{"archive/Invoices/2024", "archive/Letters"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFakeFS(files...)
+			ui := &fakeUI{answer: true}
+			opts := append([]app.Option{app.WithTaxonomy("archive", taxonomy)}, tt.opts...)
+			svc := app.NewService(fs, ai, ui, opts...)
+
+			results, err := svc.Run(context.Background(), requests(files[:3]...))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for _, path := range []string{"archive/Invoices/2024/2024-01-01_invoice.pdf", "archive/Letters/2024-01-01_letter-1.pdf"} {
+				if !fs.Exists(path) {
+					t.Errorf("%s does not exist", path)
+				}
+			}
+			c := results[2]
+			if c.Status != tt.wantC || c.NewCategory != "receipts" {
+				t.Errorf("c.pdf: %s in %q, want %s in new category receipts", c.Status, c.NewCategory, tt.wantC)
+			}
+			if tt.wantC == domain.StatusSkipped && (!errors.Is(c.Err, app.ErrNewCategory) || !fs.Exists("in/c.pdf")) {
+				t.Errorf("c.pdf: err = %v, want it left in place with ErrNewCategory", c.Err)
+			}
+			if strings.Join(ui.asked, "\n") != strings.Join(tt.wantAsk, "\n") {
+				t.Errorf("asked %q, want %q", ui.asked, tt.wantAsk)
+			}
+			if strings.Join(fs.dirs, "\n") != strings.Join(tt.wantDirs, "\n") {
+				t.Errorf("created %q, want %q", fs.dirs, tt.wantDirs)
+			}
+		})
+	}
+}
+
+// TestService_ApplyTargetTakenConcurrently simulates another process creating
+// a file under a proposed name between review and rename.
+func TestService_ApplyTargetTakenConcurrently(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.pdf", "b.pdf"}
+	ai := &fakeAI{subjects: map[string]string{"content of a.pdf": "Invoice", "content of b.pdf": "Invoice"}}
+
+	tests := []struct {
+		name   string
+		opts   []app.Option
+		wantA  domain.RenameStatus
+		wantAt string
+	}{
+		{name: "next free name", wantA: domain.StatusRenamed, wantAt: "2024-01-01_invoice-2.pdf"},
+		{name: "exact names", opts: []app.Option{app.WithExactNames()}, wantA: domain.StatusFailed, wantAt: "a.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFakeFS(files...)
+			ui := &fakeUI{}
+			svc := app.NewService(fs, ai, ui, tt.opts...)
+			results := svc.Propose(context.Background(), requests(files...))
+			fs.files["2024-01-01_invoice.pdf"] = "created by someone else"
+
+			svc.Apply(results)
+			if results[0].Status != tt.wantA || !fs.Exists(tt.wantAt) || fs.files[tt.wantAt] != "content of a.pdf" {
+				t.Errorf("a.pdf: %s, want %s at %s; files = %v", results[0].Status, tt.wantA, tt.wantAt, fs.files)
+			}
+			if tt.wantA == domain.StatusFailed && !errors.Is(results[0].Err, os.ErrExist) {
+				t.Errorf("a.pdf: err = %v, want os.ErrExist", results[0].Err)
+			}
+			if results[1].Status != domain.StatusRenamed || fs.files["2024-01-01_invoice-1.pdf"] != "content of b.pdf" {
+				t.Errorf("b.pdf: %s, want renamed to its own proposal; files = %v", results[1].Status, fs.files)
+			}
+			if fs.files["2024-01-01_invoice.pdf"] != "created by someone else" {
+				t.Error("the concurrently created file was replaced")
+			}
+		})
+	}
+}
+
+func TestService_Extension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy domain.ExtensionPolicy
+		want   string
+	}{
+		{"fixed from type", domain.ExtensionPolicy{FixFromMime: true}, "in/2024-01-01_scan.png"},
+		{"kept when not fixing", domain.ExtensionPolicy{}, "in/2024-01-01_scan.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFakeFS()
+			fs.files["in/IMG_1.JPG"] = "\x89PNG image"
+			ai := &fakeAI{subjects: map[string]string{"\x89PNG image": "Scan"}}
+			svc := app.NewService(fs, ai, &fakeUI{answer: true}, app.WithExtensionPolicy(tt.policy))
+
+			results := svc.Propose(context.Background(), requests("in/IMG_1.JPG"))
+			if got := results[0].NewPath(); got != tt.want {
+				t.Errorf("NewPath() = %q, want %q (err %v)", got, tt.want, results[0].Err)
+			}
+		})
+	}
+}
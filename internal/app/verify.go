@@ -0,0 +1,67 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// Reasons a planned rename is refused.
+var (
+	ErrContentChanged = errors.New("file has changed since it was planned")
+	ErrInvalidName    = errors.New("proposed name is not a plain filename")
+	ErrTargetTaken    = errors.New("proposed name is already taken")
+)
+
+// Verify checks proposals that were made earlier, e.g. loaded from a plan file,
+// against the disk before they are applied. A proposal fails if its file no
+// longer has the planned content, if the proposed name is not a plain filename,
+// or if the target exists or is claimed by an earlier proposal. Unlike Propose,
+// Verify never picks another name: a plan is executed exactly as written.
+func (s *Service) Verify(results []domain.RenameResult) {
+	claimed := make(map[string]struct{})
+	for i := range results {
+		r := &results[i]
+		if r.Status != domain.StatusProposed {
+			continue
+		}
+		if err := s.verify(*r, claimed); err != nil {
+			*r = failed(*r, err)
+			continue
+		}
+		claimed[filepath.Clean(r.OriginalPath)] = struct{}{}
+		claimed[r.NewPath()] = struct{}{}
+		if r.NewPath() == filepath.Clean(r.OriginalPath) {
+			r.Status = domain.StatusUnchanged
+		}
+	}
+}
+
+func (s *Service) verify(r domain.RenameResult, claimed map[string]struct{}) error {
+	name := r.ProposedName
+	if err := domain.CheckName(name); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidName, err)
+	}
+	if _, ok := claimed[filepath.Clean(r.OriginalPath)]; ok {
+		return fmt.Errorf("%w: %s is renamed by an earlier entry", ErrTargetTaken, r.OriginalName)
+	}
+
+	hash, err := s.fs.HashFile(r.OriginalPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if hash != r.ContentHash {
+		return ErrContentChanged
+	}
+
+	target := r.NewPath()
+	if target == filepath.Clean(r.OriginalPath) {
+		return nil
+	}
+	if _, ok := claimed[target]; ok || s.fs.Exists(target) {
+		return fmt.Errorf("%w: %s", ErrTargetTaken, name)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package app_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/app"
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestService_Verify(t *testing.T) {
+	t.Parallel()
+
+	fs := newFakeFS("in/a.pdf", "in/b.pdf", "in/c.pdf", "in/d.pdf", "in/taken.pdf")
+	planned := func(original, proposed string) domain.RenameResult {
+		return domain.RenameResult{
+			OriginalPath: original,
+			OriginalName: filepath.Base(original),
+			ProposedName: proposed,
+			ContentHash:  domain.ContentHash([]byte("content of " + original)),
+			Status:       domain.StatusProposed,
+		}
+	}
+	changed := planned("in/b.pdf", "b-new.pdf")
+	changed.ContentHash = domain.ContentHash([]byte("old content"))
+
+	results := []domain.RenameResult{
+		planned("in/a.pdf", "invoice.pdf"),
+		changed,
+		planned("in/c.pdf", "../escape.pdf"),
+		planned("in/d.pdf", "invoice.pdf"),
+		planned("in/taken.pdf", "taken.pdf"),
+		planned("in/missing.pdf", "x.pdf"),
+	}
+	svc := app.NewService(fs, nil, &fakeUI{})
+	svc.Verify(results)
+
+	tests := []struct {
+		status domain.RenameStatus
+		err    error
+	}{
+		{domain.StatusProposed, nil},
+		{domain.StatusFailed, app.ErrContentChanged},
+		{domain.StatusFailed, app.ErrInvalidName},
+		{domain.StatusFailed, app.ErrTargetTaken},
+		{domain.StatusUnchanged, nil},
+		{domain.StatusFailed, nil},
+	}
+	for i, tc := range tests {
+		r := results[i]
+		if r.Status != tc.status {
+			t.Errorf("results[%d].Status = %s, want %s (err: %v)", i, r.Status, tc.status, r.Err)
+		}
+		if tc.err != nil && !errors.Is(r.Err, tc.err) {
+			t.Errorf("results[%d].Err = %v, want %v", i, r.Err, tc.err)
+		}
+	}
+
+	// Apply renames only what Verify let through.
+	svc.Apply(results)
+	if !fs.Exists("in/invoice.pdf") || fs.Exists("in/a.pdf") || !fs.Exists("in/d.pdf") {
+		t.Errorf("files after Apply = %v", fs.files)
+	}
+}
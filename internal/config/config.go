@@ -0,0 +1,147 @@
+// Package config loads rnai's configuration files and named profiles into viper.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectFileName is the name of the project-level configuration file.
+const ProjectFileName = ".rnai.yaml"
+
+// Configuration keys shared by the top level of a config file and its profiles.
+const (
+	KeyProvider      = "provider"
+	KeyBaseURL       = "base_url"
+	KeyModel         = "model"
+	KeyTemplate      = "template"
+	KeyStyle         = "style"
+	KeyAllowedTypes  = "allowed_types"
+	KeyPrompt        = "prompt"
+	KeyOnCollision   = "on_collision"
+	KeyCacheTTL      = "cache_ttl"
+	KeyCandidates    = "candidates"
+	KeyMinConfidence = "min_confidence"
+	KeyOrganize      = "organize"
+	KeyUnicode       = "unicode"
+	KeyTransliterate = "transliterate"
+	KeyNormalizeExt  = "normalize_ext"
+	KeyFixExt        = "fix_ext"
+	KeyTaxonomy      = "taxonomy"
+	keyProfiles      = "profiles"
+)
+
+// UserConfigPath returns $XDG_CONFIG_HOME/rnai/config.yaml, defaulting to
+// ~/.config/rnai/config.yaml.
+func UserConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "rnai", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "rnai", "config.yaml"), nil
+}
+
+// ProjectConfigPaths returns every .rnai.yaml between the filesystem root and
+// dir, outermost first, so that the nearest file is merged last and wins.
+func ProjectConfigPaths(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	var paths []string
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			paths = append(paths, path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i, j := 0, len(paths)-1; i < j; i, j = i+1, j-1 {
+		paths[i], paths[j] = paths[j], paths[i]
+	}
+	return paths, nil
+}
+
+// Load merges the user configuration and all project configurations found from
+// dir upwards into v, then applies the named profile on top. Maps are merged key
+// by key, so a project file only needs to override what differs. It returns the
+// files that were read.
+func Load(v *viper.Viper, dir, profile string) ([]string, error) {
+	var paths []string
+	userPath, err := UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(userPath); err == nil {
+		paths = append(paths, userPath)
+	}
+	projectPaths, err := ProjectConfigPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, projectPaths...)
+
+	v.SetConfigType("yaml")
+	for _, path := range paths {
+		if err := mergeFile(v, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile == "" {
+		return paths, nil
+	}
+	key := keyProfiles + "." + profile
+	if !v.IsSet(key) {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", profile, profileList(v))
+	}
+	if err := v.MergeConfigMap(v.GetStringMap(key)); err != nil {
+		return nil, fmt.Errorf("failed to apply profile %q: %w", profile, err)
+	}
+	return paths, nil
+}
+
+func mergeFile(v *viper.Viper, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := v.MergeConfig(f); err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Profiles returns the names of all configured profiles, sorted.
+func Profiles(v *viper.Viper) []string {
+	var names []string
+	for name := range v.GetStringMap(keyProfiles) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileList(v *viper.Viper) string {
+	names := Profiles(v)
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/maltehedderich/rename-ai/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setup creates a user config and two nested project configs and returns the
+// innermost project directory. It sets XDG_CONFIG_HOME, so tests using it must
+// not run in parallel.
+func setup(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+
+	writeFile(t, filepath.Join(root, "xdg", "rnai", "config.yaml"), `
+model: user-model
+style: snake
+profiles:
+  invoices:
+    template: "{date}_{category}_{subject}{ext}"
+    allowed_types: [application/pdf]
+    prompt: Include the vendor name.
+  photos:
+    style: title
+`)
+	writeFile(t, filepath.Join(root, "work", config.ProjectFileName), `
+style: pascal
+profiles:
+  invoices:
+    model: invoice-model
+`)
+	writeFile(t, filepath.Join(root, "work", "clients", config.ProjectFileName), `
+profiles:
+  invoices:
+    on_collision: counter
+`)
+	return filepath.Join(root, "work", "clients")
+}
+
+func TestLoad(t *testing.T) {
+	dir := setup(t)
+
+	tests := []struct {
+		name    string
+		profile string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "nearest project file wins over user config",
+			profile: "",
+			want: map[string]any{
+				config.KeyModel: "user-model",
+				config.KeyStyle: "pascal",
+			},
+		},
+		{
+			name:    "profile merged across files",
+			profile: "invoices",
+			want: map[string]any{
+				config.KeyModel:        "invoice-model",
+				config.KeyStyle:        "pascal",
+				config.KeyTemplate:     "{date}_{category}_{subject}{ext}",
+				config.KeyPrompt:       "Include the vendor name.",
+				config.KeyOnCollision:  "counter",
+				config.KeyAllowedTypes: []string{"application/pdf"},
+			},
+		},
+		{
+			name:    "profile overrides project defaults",
+			profile: "photos",
+			want: map[string]any{
+				config.KeyModel: "user-model",
+				config.KeyStyle: "title",
+			},
+		},
+		{
+			name:    "unknown profile",
+			profile: "receipts",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := viper.New()
+			paths, err := config.Load(v, dir, tc.profile)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(paths) != 3 {
+				t.Errorf("Load() read %d files, want 3: %v", len(paths), paths)
+			}
+			for key, want := range tc.want {
+				var got any = v.GetString(key)
+				if _, ok := want.([]string); ok {
+					got = v.GetStringSlice(key)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	dir := setup(t)
+
+	v := viper.New()
+	if _, err := config.Load(v, dir, ""); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := config.Profiles(v); !reflect.DeepEqual(got, []string{"invoices", "photos"}) {
+		t.Errorf("Profiles() = %v", got)
+	}
+}
+
+func TestLoad_NoFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	v := viper.New()
+	paths, err := config.Load(v, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("Load() read %v, want nothing", paths)
+	}
+}
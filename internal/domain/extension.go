@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// compoundExtensions are extensions made of two parts that belong together.
+var compoundExtensions = []string{
+	".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.lz", ".tar.lzma", ".tar.z",
+}
+
+// extensionAliases maps alternative spellings to the canonical extension.
+var extensionAliases = map[string]string{
+	".jpeg":     ".jpg",
+	".jpe":      ".jpg",
+	".tiff":     ".tif",
+	".htm":      ".html",
+	".yml":      ".yaml",
+	".markdown": ".md",
+	".mpeg":     ".mpg",
+	".aif":      ".aiff",
+	".tgz":      ".tar.gz",
+}
+
+// mimeExtensions lists, for types whose content is detected reliably, the
+// extensions a file of that type may have, canonical first. Text types are
+// left out: plain text is detected for far too many formats to judge their
+// extension.
+var mimeExtensions = map[string][]string{
+	"application/pdf": {".pdf"},
+	"image/png":       {".png"},
+	"image/jpeg":      {".jpg", ".jpeg", ".jpe"},
+	"image/webp":      {".webp"},
+	"image/gif":       {".gif"},
+	"image/heic":      {".heic"},
+	"image/heif":      {".heif", ".heic"},
+	"video/mp4":       {".mp4", ".m4v"},
+	"video/quicktime": {".mov", ".qt"},
+	"video/webm":      {".webm"},
+	"video/x-msvideo": {".avi"},
+	"video/x-flv":     {".flv"},
+	"video/3gpp":      {".3gp"},
+	"audio/mpeg":      {".mp3"},
+	"audio/wav":       {".wav"},
+	"audio/x-wav":     {".wav"},
+	"audio/flac":      {".flac"},
+	"audio/ogg":       {".ogg", ".oga", ".opus"},
+	"audio/aiff":      {".aiff", ".aif"},
+	"audio/x-aiff":    {".aiff", ".aif"},
+}
+
+// ExtensionPolicy decides how the extension of a renamed file is chosen. The
+// extension is always taken from the original name or the detected type,
+// never from the AI, and always lower-cased.
+type ExtensionPolicy struct {
+	// Aliases replaces alternative spellings by the canonical one, e.g.
+	// ".jpeg" by ".jpg".
+	Aliases bool
+	// FixFromMime replaces an extension that contradicts the detected type,
+	// e.g. ".jpg" on a PNG image, by the type's canonical extension.
+	FixFromMime bool
+}
+
+// SplitExt splits name into stem and extension like filepath.Ext, but
+// recognizes compound extensions such as ".tar.gz".
+func SplitExt(name string) (stem, ext string) {
+	lower := strings.ToLower(name)
+	for _, compound := range compoundExtensions {
+		if strings.HasSuffix(lower, compound) && len(name) > len(compound) {
+			return name[:len(name)-len(compound)], name[len(name)-len(compound):]
+		}
+	}
+	ext = filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// Extension returns the extension the file at path should have under the
+// policy, given its detected MIME type. It reports whether the extension was
+// replaced, or added, because it contradicted the type.
+func (p ExtensionPolicy) Extension(path, mimeType string) (ext string, fixed bool) {
+	_, ext = SplitExt(filepath.Base(path))
+	ext = strings.ToLower(ext)
+
+	if p.FixFromMime {
+		baseMime := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+		if valid, ok := mimeExtensions[baseMime]; ok && !matchesExtension(ext, valid) {
+			ext, fixed = valid[0], true
+		}
+	}
+	if p.Aliases {
+		if canonical, ok := extensionAliases[ext]; ok {
+			ext = canonical
+		}
+	}
+	return ext, fixed
+}
+
+// matchesExtension reports whether ext, or its canonical spelling, is one of
+// valid. A missing extension matches nothing.
+func matchesExtension(ext string, valid []string) bool {
+	if ext == "" {
+		return false
+	}
+	for _, v := range valid {
+		if ext == v || extensionAliases[ext] == v || extensionAliases[v] == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestSplitExt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, stem, ext string
+	}{
+		{"backup.tar.gz", "backup", ".tar.gz"},
+		{"Backup.TAR.XZ", "Backup", ".TAR.XZ"},
+		{"report.pdf", "report", ".pdf"},
+		{"notes.v2.txt", "notes.v2", ".txt"},
+		{"README", "README", ""},
+		{".tar.gz", ".tar", ".gz"},
+	}
+	for _, tc := range tests {
+		if stem, ext := domain.SplitExt(tc.name); stem != tc.stem || ext != tc.ext {
+			t.Errorf("SplitExt(%q) = %q, %q; want %q, %q", tc.name, stem, ext, tc.stem, tc.ext)
+		}
+	}
+}
+
+func TestExtensionPolicy_Extension(t *testing.T) {
+	t.Parallel()
+
+	all := domain.ExtensionPolicy{Aliases: true, FixFromMime: true}
+	tests := []struct {
+		name      string
+		policy    domain.ExtensionPolicy
+		path      string
+		mimeType  string
+		want      string
+		wantFixed bool
+	}{
+		{"lower-cased", domain.ExtensionPolicy{}, "IMG_1.JPEG", "image/jpeg", ".jpeg", false},
+		{"alias", all, "IMG_1.JPEG", "image/jpeg", ".jpg", false},
+		{"compound", all, "in/backup.TAR.GZ", "application/gzip", ".tar.gz", false},
+		{"compound alias", all, "backup.tgz", "application/gzip", ".tar.gz", false},
+		{"png named jpg", all, "scan.jpg", "image/png", ".png", true},
+		{"png named jpg kept", domain.ExtensionPolicy{Aliases: true}, "scan.jpg", "image/png", ".jpg", false},
+		{"missing extension", all, "scan", "application/pdf", ".pdf", true},
+		{"alias of valid", all, "photo.jpe", "image/jpeg", ".jpg", false},
+		{"text not judged", all, "config.yml", "text/plain; charset=utf-8", ".yaml", false},
+		{"unknown type", all, "data.bin", "application/octet-stream", ".bin", false},
+	}
+	for _, tc := range tests {
+		got, fixed := tc.policy.Extension(tc.path, tc.mimeType)
+		if got != tc.want || fixed != tc.wantFixed {
+			t.Errorf("%s: Extension(%q, %q) = %q, %v; want %q, %v", tc.name, tc.path, tc.mimeType, got, fixed, tc.want, tc.wantFixed)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxNameBytes is the longest filename, in bytes, that common filesystems
+// such as ext4, APFS and NTFS accept.
+const MaxNameBytes = 255
+
+// Reasons a proposed filename is rejected. They are wrapped in a *NameError.
+var (
+	// ErrDotName: the name consists of dots only, such as "." or "..".
+	ErrDotName = errors.New("name consists of dots only")
+	// ErrLeadingDot: the name starts with a dot and would hide the file.
+	ErrLeadingDot = errors.New("name starts with a dot")
+	// ErrEmptyStem: there is nothing before the extension, as in ".pdf".
+	ErrEmptyStem = errors.New("name has nothing before the extension")
+	// ErrNameTooLong: the name exceeds MaxNameBytes.
+	ErrNameTooLong = errors.New("name is longer than 255 bytes")
+	// ErrDoubleExtension: the name carries a second extension, as in
+	// "invoice.pdf.pdf" or "report.docx.pdf".
+	ErrDoubleExtension = errors.New("name has a double extension")
+	// ErrPathSeparator: the name contains a path separator.
+	ErrPathSeparator = errors.New("name contains a path separator")
+)
+
+// NameError reports why a filename is not acceptable. Err is one of the
+// reasons above.
+type NameError struct {
+	Name string
+	Err  error
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid name %q: %v", e.Name, e.Err)
+}
+
+func (e *NameError) Unwrap() error {
+	return e.Err
+}
+
+// documentExtensions are extensions of file types that are never combined, so
+// one following the other is a mistake, unlike ".tar.gz" or ".zip.gpg".
+var documentExtensions = map[string]bool{
+	".pdf": true, ".txt": true, ".md": true, ".csv": true, ".json": true, ".xml": true,
+	".html": true, ".htm": true, ".doc": true, ".docx": true, ".odt": true, ".rtf": true,
+	".xls": true, ".xlsx": true, ".ods": true, ".ppt": true, ".pptx": true, ".odp": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".heic": true,
+	".heif": true, ".tif": true, ".tiff": true, ".mp3": true, ".mp4": true, ".mov": true,
+	".avi": true, ".wav": true, ".flac": true, ".ogg": true, ".webm": true, ".mkv": true,
+}
+
+// CheckName reports whether name is a safe filename to rename a file to. It
+// returns a *NameError for names that consist of dots only, start with a dot,
+// have an empty stem, exceed MaxNameBytes, carry a double extension or contain
+// a path separator.
+func CheckName(name string) error {
+	stem, ext := SplitExt(name)
+	switch {
+	case name != "" && strings.Trim(name, ".") == "":
+		return &NameError{Name: name, Err: ErrDotName}
+	case strings.ContainsAny(name, `/\`):
+		return &NameError{Name: name, Err: ErrPathSeparator}
+	case strings.Trim(stem, ".-_ ") == "":
+		return &NameError{Name: name, Err: ErrEmptyStem}
+	case strings.HasPrefix(name, "."):
+		return &NameError{Name: name, Err: ErrLeadingDot}
+	case len(name) > MaxNameBytes:
+		return &NameError{Name: name, Err: ErrNameTooLong}
+	case isDoubleExtension(filepath.Ext(stem), ext):
+		return &NameError{Name: name, Err: ErrDoubleExtension}
+	}
+	return nil
+}
+
+func isDoubleExtension(inner, outer string) bool {
+	inner, outer = strings.ToLower(inner), strings.ToLower(outer)
+	return inner != "" && (inner == outer || documentExtensions[inner] && documentExtensions[outer])
+}
+
+// RepairName fixes what can be fixed in a proposed filename without guessing:
+// leading dots are dropped, a double extension is reduced to the last one and
+// an overlong stem is truncated, at a word boundary where possible, to fit
+// MaxNameBytes with its extension. Names that cannot be repaired, because they
+// consist of dots only, have an empty stem or contain a path separator, are
+// rejected with the *NameError of CheckName.
+func RepairName(name string) (string, error) {
+	stem, ext := SplitExt(name)
+	for inner := filepath.Ext(stem); isDoubleExtension(inner, ext); inner = filepath.Ext(stem) {
+		stem = strings.TrimSuffix(stem, inner)
+	}
+	stem = strings.TrimLeft(stem, ".")
+	if stem != "" && len(stem)+len(ext) > MaxNameBytes && len(ext) < MaxNameBytes/2 {
+		stem = truncateWords(stem, MaxNameBytes-len(ext))
+	}
+
+	if strings.Trim(stem, ".-_ ") == "" {
+		if err := CheckName(name); errors.Is(err, ErrDotName) {
+			return "", err
+		}
+		return "", &NameError{Name: name, Err: ErrEmptyStem}
+	}
+	repaired := stem + ext
+	if err := CheckName(repaired); err != nil {
+		return "", err
+	}
+	return repaired, nil
+}
+
+// truncateWords shortens s to at most n bytes without splitting a character.
+// If a word separator lies in the second half of what is kept, it cuts there
+// so that no word is left incomplete. Trailing separators are removed.
+func truncateWords(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	s = s[:cut]
+	if i := strings.LastIndexAny(s, "-_. "); i >= n/2 {
+		s = s[:i]
+	}
+	return strings.TrimRight(s, "-_. ")
+}
@@ -0,0 +1,111 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestCheckName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"dated name", "2024-03-24_invoice.pdf", nil},
+		{"compound extension", "archive.tar.gz", nil},
+		{"encrypted archive", "backup.zip.gpg", nil},
+		{"no extension", "README", nil},
+		{"parent directory", "..", domain.ErrDotName},
+		{"current directory", ".", domain.ErrDotName},
+		{"empty", "", domain.ErrEmptyStem},
+		{"extension only", ".pdf", domain.ErrEmptyStem},
+		{"separators only", "-_.pdf", domain.ErrEmptyStem},
+		{"leading dot", ".hidden.pdf", domain.ErrLeadingDot},
+		{"too long", strings.Repeat("a", 252) + ".pdf", domain.ErrNameTooLong},
+		{"repeated extension", "invoice.pdf.pdf", domain.ErrDoubleExtension},
+		{"second extension", "report.DOCX.pdf", domain.ErrDoubleExtension},
+		{"path separator", "a/b.pdf", domain.ErrPathSeparator},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.CheckName(tc.input)
+			if !errors.Is(err, tc.want) || (err == nil) != (tc.want == nil) {
+				t.Errorf("CheckName(%q) = %v, want %v", tc.input, err, tc.want)
+			}
+			var nameErr *domain.NameError
+			if err != nil && !errors.As(err, &nameErr) {
+				t.Errorf("CheckName(%q) = %T, want *domain.NameError", tc.input, err)
+			}
+		})
+	}
+}
+
+func TestRepairName(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("word-", 60) + "end"
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"valid", "invoice.pdf", "invoice.pdf", nil},
+		{"leading dot", ".hidden.pdf", "hidden.pdf", nil},
+		{"leading dots", "..invoice.pdf", "invoice.pdf", nil},
+		{"repeated extension", "invoice.pdf.pdf", "invoice.pdf", nil},
+		{"second extension", "report.docx.pdf", "report.pdf", nil},
+		{"compound extension", "archive.tar.gz", "archive.tar.gz", nil},
+		{"too long", long + ".pdf", strings.Repeat("word-", 49) + "word.pdf", nil},
+		{"parent directory", "..", "", domain.ErrDotName},
+		{"extension only", ".pdf", "", domain.ErrEmptyStem},
+		{"repeated extension only", ".pdf.pdf", "", domain.ErrEmptyStem},
+		{"path separator", "a/b.pdf", "", domain.ErrPathSeparator},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := domain.RepairName(tc.input)
+			if got != tc.want || !errors.Is(err, tc.wantErr) || (err == nil) != (tc.wantErr == nil) {
+				t.Errorf("RepairName(%q) = %q, %v; want %q, %v", tc.input, got, err, tc.want, tc.wantErr)
+			}
+			if err == nil && len(got) > domain.MaxNameBytes {
+				t.Errorf("RepairName(%q) = %d bytes", tc.input, len(got))
+			}
+		})
+	}
+}
+
+func TestTemplate_RenderShortensSubject(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := domain.ParseTemplate("{date}_{category}_{subject}{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]string{
+		"date":     "2024-03-24",
+		"category": "invoice",
+		"subject":  strings.Repeat("Quarterly Report ", 30) + "Ünïcödé",
+	}
+	got, err := tmpl.Render(fields, ".PDF", domain.StyleKebab)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(got) > domain.MaxNameBytes || !strings.HasPrefix(got, "2024-03-24_invoice_quarterly-report-") || !strings.HasSuffix(got, "-quarterly.pdf") {
+		t.Errorf("Render() = %q (%d bytes), want the subject shortened at a word boundary", got, len(got))
+	}
+
+	fields["subject"] = "Invoice.pdf"
+	if got, err := tmpl.Render(fields, ".pdf", domain.StylePreserve); err != nil || got != "2024-03-24_invoice_Invoice.pdf" {
+		t.Errorf("Render() = %q, %v; want the double extension removed", got, err)
+	}
+}
@@ -14,20 +14,186 @@ type RenameRequest struct {
 	Extension    string
 }
 
+// RenameStatus is the outcome of the rename flow for a single file.
+type RenameStatus string
+
+const (
+	StatusProposed  RenameStatus = "proposed"  // a new name is proposed but not applied yet
+	StatusUnchanged RenameStatus = "unchanged" // the proposed name is the current name
+	StatusRenamed   RenameStatus = "renamed"   // the file was renamed
+	StatusSkipped   RenameStatus = "skipped"   // the proposal was not applied (dry-run or declined)
+	StatusFailed    RenameStatus = "failed"    // see Err
+)
+
 type RenameResult struct {
+	OriginalPath string
 	OriginalName string
 	ProposedName string
 	Reasoning    string
+	Fields       map[string]string // AI values the proposed name was rendered from
+	Confidence   float64           // the model's confidence in the proposal, 0 to 1
+	Candidates   []Candidate       // all candidates, best first, if more than one was requested
+	TargetDir    string            // directory the file is moved to when organizing, see Dir
+	NewCategory  string            // category proposed by the AI that is not in the taxonomy yet
+	Replaces     bool              // the file at NewPath is replaced, see CollisionDedupe and CollisionKeepNewer
+	ReplacedHash string            // content hash the replaced file must still have when it is replaced
+	Taken        string            // name the AI proposed that was taken; ProposedName needs approval, see CollisionAsk
+	MimeType     string
+	ContentHash  string
+	Status       RenameStatus
+	Err          error
+}
+
+// Dir returns the directory the file is, or would be, renamed into: the
+// target directory when organizing, otherwise its current directory.
+func (r RenameResult) Dir() string {
+	if r.TargetDir != "" {
+		return r.TargetDir
+	}
+	return filepath.Dir(r.OriginalPath)
+}
+
+// NewPath returns the path the file is, or would be, renamed to.
+func (r RenameResult) NewPath() string {
+	return filepath.Join(r.Dir(), r.ProposedName)
 }
 
+// NameRequest is what an AI provider needs to propose a name for one file.
+type NameRequest struct {
+	Content   []byte
+	MimeType  string
+	Extension string
+	// Name is the current filename, for providers that cannot read the content.
+	Name string
+	// Template is the layout the fields will be assembled into (for the prompt only).
+	Template string
+	// Fields are the template fields the provider must fill in.
+	Fields []string
+	// Instructions are additional, user-supplied prompt instructions.
+	Instructions string
+	// Candidates is how many ranked candidates are wanted; less than 2 means one.
+	Candidates int
+	// Categories are the taxonomy's categories the FieldCategory field should
+	// be chosen from, if the file is being organized.
+	Categories []string
+	// History holds earlier suggestions for the same file that the user asked
+	// to improve, oldest first.
+	History []Revision
+}
+
+// Revision is an earlier suggestion, the name it was rendered into and the
+// user's hint on how to improve it.
+type Revision struct {
+	Suggestion NameSuggestion
+	Name       string
+	Hint       string
+}
+
+// NameSuggestion is a provider's answer: a value for every requested field and
+// an explanation of the choice.
+type NameSuggestion struct {
+	Fields    map[string]string
+	Reasoning string
+	// Confidence is the model's own estimate, from 0 to 1, that the values are right.
+	Confidence float64
+	// Alternatives are further candidates, best first, if more than one was requested.
+	Alternatives []NameSuggestion
+}
+
+// Candidate is one suggestion rendered into a filename.
+type Candidate struct {
+	Name        string
+	Dir         string // target directory when organizing
+	NewCategory string // set if the category is not in the taxonomy
+	Reasoning   string
+	Confidence  float64
+	Fields      map[string]string
+}
+
+var (
+	// invalidChars matches runs of characters that are not allowed in
+	// filenames: anything but letters, digits and '.', '_' and '-'. Letters and
+	// digits of every script are allowed; see Transliterator for limiting
+	// names to ASCII.
+	invalidChars = regexp.MustCompile(`[^\p{L}\p{M}\p{N}._-]+`)
+	// repeatedSeparators matches runs of two or more separators.
+	repeatedSeparators = regexp.MustCompile(`[._-]{2,}`)
+)
+
 // SanitizeFilename removes invalid characters and enforces kebab-case
 func SanitizeFilename(name string) string {
-	// Remove invalid characters for filenames (this is a simplified regex)
-	reg, _ := regexp.Compile("[^a-zA-Z0-9._-]+")
-	safe := reg.ReplaceAllString(name, "-")
-
 	// Enforce lower case for kebab-case
-	return strings.ToLower(safe)
+	return strings.ToLower(replaceInvalidChars(name))
+}
+
+// replaceInvalidChars replaces every run of invalid characters with a single dash
+// without changing the case of the remaining characters. Runs of separators
+// are collapsed into one, a dot if the run has one, so "a - b" becomes "a-b"
+// and "report-.pdf" becomes "report.pdf".
+func replaceInvalidChars(name string) string {
+	name = invalidChars.ReplaceAllString(name, "-")
+	return repeatedSeparators.ReplaceAllStringFunc(name, func(run string) string {
+		if strings.Contains(run, ".") {
+			return "."
+		}
+		return run[:1]
+	})
+}
+
+// CollisionStrategy decides what happens when a proposed name is already taken.
+type CollisionStrategy string
+
+const (
+	// CollisionCounter appends -1, -2, ... until the name is free.
+	CollisionCounter CollisionStrategy = "counter"
+	// CollisionHash appends the start of the file's content hash, and a counter
+	// should that be taken too.
+	CollisionHash CollisionStrategy = "hash"
+	// CollisionSkip leaves the file as it is.
+	CollisionSkip CollisionStrategy = "skip"
+	// CollisionAsk asks whether to use the next free name or leave the file.
+	CollisionAsk CollisionStrategy = "ask"
+	// CollisionDedupe replaces the existing file if it has the same content, so
+	// only one copy remains; otherwise it falls back to CollisionCounter.
+	CollisionDedupe CollisionStrategy = "dedupe"
+	// CollisionKeepNewer replaces the existing file if the file being renamed
+	// was modified later, and otherwise leaves the file being renamed as it is.
+	CollisionKeepNewer CollisionStrategy = "keep-newer"
+)
+
+// CollisionStrategies lists all supported strategies in the order they are
+// documented.
+var CollisionStrategies = []CollisionStrategy{
+	CollisionCounter, CollisionHash, CollisionSkip, CollisionAsk, CollisionDedupe, CollisionKeepNewer,
+}
+
+// ParseCollisionStrategy validates a user-supplied collision strategy. An empty
+// string selects the default, CollisionCounter.
+func ParseCollisionStrategy(s string) (CollisionStrategy, error) {
+	if s == "" {
+		return CollisionCounter, nil
+	}
+	for _, strategy := range CollisionStrategies {
+		if strings.EqualFold(s, string(strategy)) {
+			return strategy, nil
+		}
+	}
+	names := make([]string, len(CollisionStrategies))
+	for i, strategy := range CollisionStrategies {
+		names[i] = string(strategy)
+	}
+	return "", fmt.Errorf("unknown collision strategy %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+// hashSuffixLen is how many hex digits of the content hash HashSuffixName
+// appends.
+const hashSuffixLen = 8
+
+// HashSuffixName appends the start of contentHash to the stem of name, e.g.
+// "invoice-3f2a9c1d.pdf".
+func HashSuffixName(name, contentHash string) string {
+	stem, ext := SplitExt(name)
+	return fmt.Sprintf("%s-%s%s", stem, contentHash[:min(hashSuffixLen, len(contentHash))], ext)
 }
 
 // ResolveCollision checks if a file exists and appends a counter if it does.
@@ -38,8 +204,7 @@ func ResolveCollision(baseName string, checkExists func(string) bool) string {
 		return baseName
 	}
 
-	ext := filepath.Ext(baseName)
-	nameWithoutExt := strings.TrimSuffix(baseName, ext)
+	nameWithoutExt, ext := SplitExt(baseName)
 
 	counter := 1
 	for {
@@ -33,7 +33,7 @@ func TestSanitizeFilename(t *testing.T) {
 		{
 			name:     "multiple special chars",
 			input:    "foo@bar#baz!.txt",
-			expected: "foo-bar-baz-.txt",
+			expected: "foo-bar-baz.txt",
 		},
 		{
 			name:     "preserve underscores and dashes",
@@ -81,6 +81,12 @@ func TestResolveCollision(t *testing.T) {
 			existingFiles: []string{"file.txt", "file-1.txt"},
 			expected:      "file-2.txt",
 		},
+		{
+			name:          "compound extension",
+			baseName:      "backup.tar.gz",
+			existingFiles: []string{"backup.tar.gz"},
+			expected:      "backup-1.tar.gz",
+		},
 	}
 
 	for _, tc := range tests {
@@ -104,3 +110,49 @@ func TestResolveCollision(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCollisionStrategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    domain.CollisionStrategy
+		wantErr bool
+	}{
+		{"", domain.CollisionCounter, false},
+		{"counter", domain.CollisionCounter, false},
+		{"Counter", domain.CollisionCounter, false},
+		{"hash", domain.CollisionHash, false},
+		{"keep-newer", domain.CollisionKeepNewer, false},
+		{"DEDUPE", domain.CollisionDedupe, false},
+		{"clobber", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := domain.ParseCollisionStrategy(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseCollisionStrategy(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseCollisionStrategy(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHashSuffixName(t *testing.T) {
+	t.Parallel()
+
+	hash := domain.ContentHash([]byte("content"))
+	for name, want := range map[string]string{
+		"invoice.pdf":   "invoice-" + hash[:8] + ".pdf",
+		"backup.tar.gz": "backup-" + hash[:8] + ".tar.gz",
+		"README":        "README-" + hash[:8],
+	} {
+		if got := domain.HashSuffixName(name, hash); got != want {
+			t.Errorf("HashSuffixName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Style is a naming convention applied to the subject of a generated filename.
+type Style string
+
+const (
+	StyleKebab    Style = "kebab"    // 2024-01-02_budget-report.pdf
+	StyleSnake    Style = "snake"    // 2024-01-02_budget_report.pdf
+	StyleCamel    Style = "camel"    // 2024-01-02_budgetReport.pdf
+	StylePascal   Style = "pascal"   // 2024-01-02_BudgetReport.pdf
+	StyleTitle    Style = "title"    // 2024-01-02_Budget-Report.pdf
+	StylePreserve Style = "preserve" // name as proposed, only invalid characters replaced
+)
+
+// Styles lists all supported styles in the order they are documented.
+var Styles = []Style{StyleKebab, StyleSnake, StyleCamel, StylePascal, StyleTitle, StylePreserve}
+
+// ParseStyle validates a user-supplied style name.
+func ParseStyle(s string) (Style, error) {
+	for _, style := range Styles {
+		if strings.EqualFold(s, string(style)) {
+			return style, nil
+		}
+	}
+	names := make([]string, len(Styles))
+	for i, style := range Styles {
+		names[i] = string(style)
+	}
+	return "", fmt.Errorf("unknown style %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+var wordSeparator = regexp.MustCompile(`[^\p{L}\p{M}\p{N}]+`)
+
+// splitWords splits text on non-alphanumeric characters and CamelCase boundaries.
+func splitWords(text string) []string {
+	var words []string
+	for _, chunk := range wordSeparator.Split(text, -1) {
+		words = append(words, splitCamel(chunk)...)
+	}
+	return words
+}
+
+// splitCamel splits "BudgetReport" into "Budget", "Report" and "PDFExport2024"
+// into "PDF", "Export2024". Digits stay attached to the preceding word, and a
+// single trailing capital is not split off, so "GmbH" stays one word.
+func splitCamel(s string) []string {
+	if s == "" {
+		return nil
+	}
+	runes := []rune(s)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		lowerToUpper := (unicode.IsLower(prev) || unicode.IsDigit(prev)) && unicode.IsUpper(cur) && i+1 < len(runes)
+		acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// FormatWords formats free text, such as the value of a single template field,
+// in the style. StylePreserve keeps the text apart from replacing invalid characters.
+func (s Style) FormatWords(text string) string {
+	if s == StylePreserve {
+		return strings.Trim(replaceInvalidChars(strings.TrimSpace(text)), "-")
+	}
+	return s.joinSubject(splitWords(text))
+}
+
+func (s Style) joinSubject(words []string) string {
+	switch s {
+	case StyleSnake:
+		return joinWords(words, "_", strings.ToLower, strings.ToLower)
+	case StyleCamel:
+		return joinWords(words, "", strings.ToLower, capitalize)
+	case StylePascal:
+		return joinWords(words, "", capitalize, capitalize)
+	case StyleTitle:
+		return joinWords(words, "-", capitalize, capitalize)
+	default:
+		return joinWords(words, "-", strings.ToLower, strings.ToLower)
+	}
+}
+
+// Sanitize cleans a name typed by the user without re-assembling it. For
+// StyleKebab it is SanitizeFilename; other styles keep the user's casing.
+func (s Style) Sanitize(name string) string {
+	if s == StyleKebab {
+		return SanitizeFilename(name)
+	}
+	return replaceInvalidChars(name)
+}
+
+func joinWords(words []string, sep string, first, rest func(string) string) string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		if i == 0 {
+			out[i] = first(w)
+		} else {
+			out[i] = rest(w)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+func capitalize(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	if r == utf8.RuneError {
+		return w
+	}
+	return string(unicode.ToUpper(r)) + strings.ToLower(w[size:])
+}
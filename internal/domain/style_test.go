@@ -0,0 +1,87 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestParseStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    domain.Style
+		wantErr bool
+	}{
+		{"kebab", domain.StyleKebab, false},
+		{"Snake", domain.StyleSnake, false},
+		{"PASCAL", domain.StylePascal, false},
+		{"preserve", domain.StylePreserve, false},
+		{"screaming", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := domain.ParseStyle(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseStyle(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseStyle(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStyle_FormatWords(t *testing.T) {
+	t.Parallel()
+
+	const input = "Budget-Report Q1"
+	tests := []struct {
+		style domain.Style
+		input string
+		want  string
+	}{
+		{domain.StyleKebab, input, "budget-report-q1"},
+		{domain.StyleSnake, input, "budget_report_q1"},
+		{domain.StyleCamel, input, "budgetReportQ1"},
+		{domain.StylePascal, input, "BudgetReportQ1"},
+		{domain.StyleTitle, input, "Budget-Report-Q1"},
+		{domain.StylePreserve, input, "Budget-Report-Q1"},
+		{domain.StyleKebab, "BudgetReport", "budget-report"},
+		{domain.StyleSnake, "Q1FinancialPDFExport", "q1_financial_pdf_export"},
+		{domain.StylePascal, "quarterly budget", "QuarterlyBudget"},
+		{domain.StylePreserve, "foo@bar#baz!", "foo-bar-baz"},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.style)+"/"+tc.input, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.style.FormatWords(tc.input); got != tc.want {
+				t.Errorf("%s.FormatWords(%q) = %q, want %q", tc.style, tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStyle_Sanitize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		style domain.Style
+		input string
+		want  string
+	}{
+		{domain.StyleKebab, "Client Invoice.PDF", "client-invoice.pdf"},
+		{domain.StylePascal, "Client Invoice.PDF", "Client-Invoice.PDF"},
+		{domain.StylePreserve, "a/b.txt", "a-b.txt"},
+	}
+	for _, tc := range tests {
+		if got := tc.style.Sanitize(tc.input); got != tc.want {
+			t.Errorf("%s.Sanitize(%q) = %q, want %q", tc.style, tc.input, got, tc.want)
+		}
+	}
+}
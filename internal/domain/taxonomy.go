@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FieldCategory is the template field through which the AI files content into
+// a category of the taxonomy when organizing.
+const FieldCategory = "category"
+
+// Taxonomy is the set of folders files are organized into. Each folder is a
+// path template such as "Invoices/{date:2006}" or "Contracts/{client}": the
+// first segment is the literal category name the AI chooses, and the other
+// segments are rendered from the AI's fields like a filename template.
+type Taxonomy struct {
+	folders []folder
+}
+
+type folder struct {
+	category string
+	segments [][]segment // one per path segment after the category
+}
+
+// ParseTaxonomy parses and validates the folder templates of a taxonomy.
+// Categories must be unique, ignoring case. An empty taxonomy is valid: every
+// category the AI proposes is then new.
+func ParseTaxonomy(entries []string) (*Taxonomy, error) {
+	t := &Taxonomy{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		parts := strings.Split(strings.Trim(entry, "/"), "/")
+		category := parts[0]
+		if category == "" || strings.ContainsAny(category, "{}") || !literalPattern.MatchString(category) || category == "." || category == ".." {
+			return nil, fmt.Errorf("invalid taxonomy folder %q: must start with a plain category name", entry)
+		}
+		key := strings.ToLower(category)
+		if seen[key] {
+			return nil, fmt.Errorf("invalid taxonomy: category %q is listed twice", category)
+		}
+		seen[key] = true
+
+		f := folder{category: category}
+		for _, part := range parts[1:] {
+			segments, err := parseSegments(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid taxonomy folder %q: %w", entry, err)
+			}
+			for _, seg := range segments {
+				if seg.field == FieldExt {
+					return nil, fmt.Errorf("invalid taxonomy folder %q: {%s} is not allowed in folders", entry, FieldExt)
+				}
+			}
+			if len(segments) == 0 || (len(segments) == 1 && (segments[0].literal == "." || segments[0].literal == "..")) {
+				return nil, fmt.Errorf("invalid taxonomy folder %q: empty or relative path segment", entry)
+			}
+			f.segments = append(f.segments, segments)
+		}
+		t.folders = append(t.folders, f)
+	}
+	return t, nil
+}
+
+// Categories returns the category names in configuration order.
+func (t *Taxonomy) Categories() []string {
+	categories := make([]string, len(t.folders))
+	for i, f := range t.folders {
+		categories[i] = f.category
+	}
+	return categories
+}
+
+// Fields returns the distinct fields the AI has to provide to render any
+// folder, starting with FieldCategory.
+func (t *Taxonomy) Fields() []string {
+	fields := []string{FieldCategory}
+	seen := map[string]bool{FieldCategory: true}
+	for _, f := range t.folders {
+		for _, segments := range f.segments {
+			for _, field := range segmentFields(segments) {
+				if !seen[field] {
+					seen[field] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// Render returns the folder, relative to the organize root, for the category
+// the AI chose. A category that matches no folder, ignoring case, is new: it
+// is returned as a single folder formatted in the style, and isNew is true.
+func (t *Taxonomy) Render(fields map[string]string, style Style) (dir string, isNew bool, err error) {
+	category := strings.TrimSpace(fields[FieldCategory])
+	for _, f := range t.folders {
+		if !strings.EqualFold(f.category, category) {
+			continue
+		}
+		parts := []string{f.category}
+		for _, segments := range f.segments {
+			part, err := renderSegments(segments, fields, "", style)
+			if err != nil {
+				return "", false, err
+			}
+			if part, err = folderName(part); err != nil {
+				return "", false, err
+			}
+			parts = append(parts, part)
+		}
+		return path.Join(parts...), false, nil
+	}
+
+	if category == "" {
+		return "", false, fmt.Errorf("AI response is missing field %q", FieldCategory)
+	}
+	if dir, err = folderName(style.FormatWords(category)); err != nil {
+		return "", false, err
+	}
+	return dir, true, nil
+}
+
+// folderName repairs and validates a rendered folder name like a filename, so
+// that it can neither hide the folder behind a leading dot nor end up empty.
+// Leading dots are dropped first: a folder has no extension, so ".hidden" is
+// not an empty name with the extension ".hidden".
+func folderName(name string) (string, error) {
+	repaired, err := RepairName(strings.TrimLeft(name, "."))
+	if err != nil {
+		return "", fmt.Errorf("AI returned an invalid folder name: %w", err)
+	}
+	return repaired, nil
+}
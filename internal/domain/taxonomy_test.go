@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	tax, err := ParseTaxonomy([]string{"Invoices/{date:2006}", "Contracts/{client}/", "Receipts"})
+	if err != nil {
+		t.Fatalf("ParseTaxonomy() error = %v", err)
+	}
+	if got := tax.Categories(); !reflect.DeepEqual(got, []string{"Invoices", "Contracts", "Receipts"}) {
+		t.Errorf("Categories() = %v", got)
+	}
+	if got := tax.Fields(); !reflect.DeepEqual(got, []string{"category", "date", "client"}) {
+		t.Errorf("Fields() = %v", got)
+	}
+
+	for _, entries := range [][]string{
+		{"{category}/x"},
+		{"Invoices", "invoices/{date}"},
+		{"Invoices/{ext}"},
+		{"Invoices/.."},
+		{"Invoices/a b"},
+		{""},
+	} {
+		if _, err := ParseTaxonomy(entries); err == nil {
+			t.Errorf("ParseTaxonomy(%q) returned no error", entries)
+		}
+	}
+}
+
+func TestTaxonomy_Render(t *testing.T) {
+	t.Parallel()
+
+	tax, _ := ParseTaxonomy([]string{"Invoices/{date:2006}", "Contracts/{client}"})
+	tests := []struct {
+		name    string
+		style   Style
+		fields  map[string]string
+		wantDir string
+		wantNew bool
+		wantErr bool
+	}{
+		{"dated folder", StyleKebab, map[string]string{"category": "invoices", "date": "2024-03-01"}, "Invoices/2024", false, false},
+		{"field folder", StyleKebab, map[string]string{"category": "Contracts", "client": "Acme Corp"}, "Contracts/acme-corp", false, false},
+		{"new category", StyleKebab, map[string]string{"category": "Tax Returns"}, "tax-returns", true, false},
+		{"missing field", StyleKebab, map[string]string{"category": "Contracts"}, "", false, true},
+		{"missing category", StyleKebab, map[string]string{}, "", false, true},
+		{"hidden folder", StylePreserve, map[string]string{"category": "Contracts", "client": ".hidden"}, "Contracts/hidden", false, false},
+		{"hidden new category", StylePreserve, map[string]string{"category": ".Tax"}, "Tax", true, false},
+		{"folder empty after sanitizing", StylePreserve, map[string]string{"category": "Contracts", "client": "?*"}, "", false, true},
+		{"dots only", StylePreserve, map[string]string{"category": "Contracts", "client": ".."}, "", false, true},
+		{"new category empty after sanitizing", StylePreserve, map[string]string{"category": "//"}, "", false, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir, isNew, err := tax.Render(tc.fields, tc.style)
+			if (err != nil) != tc.wantErr || dir != tc.wantDir || isNew != tc.wantNew {
+				t.Errorf("Render() = %q, %v, %v; want %q, %v, error %v", dir, isNew, err, tc.wantDir, tc.wantNew, tc.wantErr)
+			}
+		})
+	}
+}
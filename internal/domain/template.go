@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate reproduces the classic YYYY-MM-DD_Subject-Title.ext structure.
+const DefaultTemplate = "{date}_{subject}{ext}"
+
+// Reserved template fields. Every other placeholder is filled in by the AI.
+const (
+	FieldDate = "date" // ISO 8601 date returned by the AI, reformatted with the placeholder's layout
+	FieldExt  = "ext"  // extension of the original file, never asked from the AI
+)
+
+// isoDate is the layout the AI must use for the date field.
+const isoDate = "2006-01-02"
+
+var (
+	fieldName      = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	literalPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]*$`)
+)
+
+// Template describes how a filename is assembled from named fields, e.g.
+// "{date:2006-01-02}_{category}_{subject}{ext}". Placeholders are written as
+// {name} or {name:format}; only the date field accepts a format, which is a Go
+// time layout.
+type Template struct {
+	raw      string
+	segments []segment
+}
+
+type segment struct {
+	literal string
+	field   string
+	format  string
+}
+
+// ParseTemplate parses and validates a filename template. If the template has no
+// {ext} placeholder, the extension is appended at the end.
+func ParseTemplate(s string) (*Template, error) {
+	t := &Template{raw: s}
+	segments, err := parseSegments(s)
+	if err != nil {
+		return nil, err
+	}
+	t.segments = segments
+
+	if len(t.Fields()) == 0 {
+		return nil, fmt.Errorf("invalid template %q: no fields for the AI to fill in", s)
+	}
+	if !slices.ContainsFunc(segments, func(seg segment) bool { return seg.field == FieldExt }) {
+		t.segments = append(t.segments, segment{field: FieldExt})
+	}
+	return t, nil
+}
+
+// parseSegments splits a template into literals and placeholders.
+func parseSegments(s string) ([]segment, error) {
+	var segments []segment
+	addLiteral := func(lit string) error {
+		if lit == "" {
+			return nil
+		}
+		if !literalPattern.MatchString(lit) {
+			return fmt.Errorf("invalid template %q: literal %q may only contain letters, digits, '.', '_' and '-'", s, lit)
+		}
+		segments = append(segments, segment{literal: lit})
+		return nil
+	}
+
+	rest := s
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			if err := addLiteral(rest); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("invalid template %q: unexpected '}'", s)
+		}
+		if err := addLiteral(rest[:open]); err != nil {
+			return nil, err
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("invalid template %q: unclosed '{'", s)
+		}
+
+		name, format, _ := strings.Cut(rest[open+1:open+1+end], ":")
+		if !fieldName.MatchString(name) {
+			return nil, fmt.Errorf("invalid template %q: bad field name %q", s, name)
+		}
+		if format != "" && name != FieldDate {
+			return nil, fmt.Errorf("invalid template %q: only {%s} accepts a format", s, FieldDate)
+		}
+		segments = append(segments, segment{field: name, format: format})
+		rest = rest[open+end+2:]
+	}
+	return segments, nil
+}
+
+// String returns the template as written by the user.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Fields returns the distinct fields the AI has to provide, in template order.
+func (t *Template) Fields() []string {
+	return segmentFields(t.segments)
+}
+
+func segmentFields(segments []segment) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, seg := range segments {
+		if seg.field == "" || seg.field == FieldExt || seen[seg.field] {
+			continue
+		}
+		seen[seg.field] = true
+		fields = append(fields, seg.field)
+	}
+	return fields
+}
+
+// Render assembles the filename from the AI-provided field values. Every value
+// is formatted in the given style; the date must be an ISO 8601 date and is
+// re-formatted with the placeholder's layout. The extension is lower-cased. If
+// the name would exceed MaxNameBytes, the longest values, usually the subject,
+// are shortened so that the date and extension are kept. The result is
+// repaired and checked with RepairName.
+func (t *Template) Render(fields map[string]string, ext string, style Style) (string, error) {
+	name, err := renderSegments(t.segments, fields, ext, style)
+	if err != nil {
+		return "", err
+	}
+	return RepairName(name)
+}
+
+func renderSegments(segments []segment, fields map[string]string, ext string, style Style) (string, error) {
+	parts := make([]string, len(segments))
+	var shortenable []int // parts holding AI values that may be truncated
+	for i, seg := range segments {
+		switch seg.field {
+		case "":
+			parts[i] = seg.literal
+		case FieldExt:
+			parts[i] = strings.ToLower(ext)
+		case FieldDate:
+			value := strings.TrimSpace(fields[FieldDate])
+			date, err := time.Parse(isoDate, value)
+			if err != nil {
+				return "", fmt.Errorf("AI returned an invalid date %q: expected YYYY-MM-DD", value)
+			}
+			layout := seg.format
+			if layout == "" {
+				layout = isoDate
+			}
+			parts[i] = replaceInvalidChars(date.Format(layout))
+		default:
+			value := style.FormatWords(fields[seg.field])
+			if value == "" {
+				return "", fmt.Errorf("AI response is missing field %q", seg.field)
+			}
+			parts[i] = value
+			shortenable = append(shortenable, i)
+		}
+	}
+	shorten(parts, shortenable)
+	return strings.Join(parts, ""), nil
+}
+
+// shorten truncates the longest of the shortenable parts, one at a time, until
+// all parts together fit into MaxNameBytes.
+func shorten(parts []string, shortenable []int) {
+	for {
+		excess := -MaxNameBytes
+		for _, part := range parts {
+			excess += len(part)
+		}
+		if excess <= 0 || len(shortenable) == 0 {
+			return
+		}
+		longest := shortenable[0]
+		for _, i := range shortenable[1:] {
+			if len(parts[i]) > len(parts[longest]) {
+				longest = i
+			}
+		}
+		if len(parts[longest]) <= 1 {
+			return
+		}
+		parts[longest] = truncateWords(parts[longest], max(len(parts[longest])-excess, 1))
+	}
+}
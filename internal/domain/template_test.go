@@ -0,0 +1,139 @@
+package domain_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestParseTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		template   string
+		wantFields []string
+		wantErr    bool
+	}{
+		{"default", domain.DefaultTemplate, []string{"date", "subject"}, false},
+		{"date layout and category", "{date:2006-01-02}_{category}_{subject}{ext}", []string{"date", "category", "subject"}, false},
+		{"repeated field", "{subject}-{date}-{subject}", []string{"subject", "date"}, false},
+		{"custom field", "{client_name}_{subject}", []string{"client_name", "subject"}, false},
+		{"unclosed brace", "{date_{subject}", nil, true},
+		{"stray closing brace", "date}_{subject}", nil, true},
+		{"bad field name", "{Date}_{subject}", nil, true},
+		{"format on non-date field", "{subject:upper}", nil, true},
+		{"path separator in literal", "{date}/{subject}", nil, true},
+		{"only extension", "file{ext}", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := domain.ParseTemplate(tc.template)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseTemplate(%q) error = %v, wantErr %v", tc.template, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := tmpl.Fields(); !reflect.DeepEqual(got, tc.wantFields) {
+				t.Errorf("Fields() = %v, want %v", got, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]string{
+		"date":     "2024-03-24",
+		"category": "Invoice",
+		"subject":  "Acme Hosting GmbH",
+	}
+	tests := []struct {
+		name     string
+		template string
+		fields   map[string]string
+		ext      string
+		style    domain.Style
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "default template",
+			template: domain.DefaultTemplate,
+			fields:   fields,
+			ext:      ".pdf",
+			style:    domain.StyleKebab,
+			want:     "2024-03-24_acme-hosting-gmbh.pdf",
+		},
+		{
+			name:     "category and custom date layout",
+			template: "{date:200601}_{category}_{subject}{ext}",
+			fields:   fields,
+			ext:      ".PDF",
+			style:    domain.StylePascal,
+			want:     "202403_Invoice_AcmeHostingGmbh.pdf",
+		},
+		{
+			name:     "extension appended when missing",
+			template: "{subject}",
+			fields:   fields,
+			ext:      ".pdf",
+			style:    domain.StyleSnake,
+			want:     "acme_hosting_gmbh.pdf",
+		},
+		{
+			name:     "preserve keeps casing",
+			template: "{category}-{subject}{ext}",
+			fields:   fields,
+			ext:      ".pdf",
+			style:    domain.StylePreserve,
+			want:     "Invoice-Acme-Hosting-GmbH.pdf",
+		},
+		{
+			name:     "unsafe characters in values",
+			template: domain.DefaultTemplate,
+			fields:   map[string]string{"date": "2024-03-24", "subject": "../../etc/passwd"},
+			ext:      ".txt",
+			style:    domain.StyleKebab,
+			want:     "2024-03-24_etc-passwd.txt",
+		},
+		{
+			name:     "invalid date",
+			template: domain.DefaultTemplate,
+			fields:   map[string]string{"date": "March 2024", "subject": "x"},
+			ext:      ".pdf",
+			style:    domain.StyleKebab,
+			wantErr:  true,
+		},
+		{
+			name:     "missing field",
+			template: "{date}_{category}{ext}",
+			fields:   map[string]string{"date": "2024-03-24"},
+			ext:      ".pdf",
+			style:    domain.StyleKebab,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := domain.ParseTemplate(tc.template)
+			if err != nil {
+				t.Fatalf("ParseTemplate(%q) error = %v", tc.template, err)
+			}
+			got, err := tmpl.Render(tc.fields, tc.ext, tc.style)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Render() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
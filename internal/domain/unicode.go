@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeMode decides what happens to characters outside ASCII in names.
+type UnicodeMode string
+
+const (
+	// UnicodeTranslit transliterates what has an ASCII form (Müller Straße ->
+	// Mueller Strasse, café -> cafe) and keeps letters of other scripts, such as
+	// CJK, as they are.
+	UnicodeTranslit UnicodeMode = "translit"
+	// UnicodeASCII transliterates like UnicodeTranslit and removes everything
+	// that is still not ASCII.
+	UnicodeASCII UnicodeMode = "ascii"
+	// UnicodeKeep keeps letters and digits of every script, only normalized.
+	UnicodeKeep UnicodeMode = "keep"
+)
+
+// UnicodeModes lists all supported modes in the order they are documented.
+var UnicodeModes = []UnicodeMode{UnicodeTranslit, UnicodeASCII, UnicodeKeep}
+
+// ParseUnicodeMode validates a user-supplied Unicode mode. An empty string
+// selects the default, UnicodeTranslit.
+func ParseUnicodeMode(s string) (UnicodeMode, error) {
+	if s == "" {
+		return UnicodeTranslit, nil
+	}
+	for _, mode := range UnicodeModes {
+		if strings.EqualFold(s, string(mode)) {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(UnicodeModes))
+	for i, mode := range UnicodeModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown unicode mode %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+// ErrNoASCII: in UnicodeASCII mode, a field consists only of characters that
+// have no ASCII form, such as CJK, and would be dropped entirely.
+var ErrNoASCII = errors.New("has no ASCII representation")
+
+// defaultTransliterations are letters whose ASCII form is not their base
+// letter. Upper-case forms are derived. Everything else with an ASCII base
+// letter loses its accents.
+var defaultTransliterations = map[string]string{
+	"ä": "ae", "ö": "oe", "ü": "ue", "ß": "ss",
+	"æ": "ae", "œ": "oe", "ø": "o", "ł": "l", "đ": "d", "ð": "d", "þ": "th", "ı": "i", "ħ": "h",
+}
+
+// Transliterator prepares text for a filename according to a UnicodeMode.
+type Transliterator struct {
+	mode  UnicodeMode
+	table map[rune]string
+}
+
+// NewTransliterator returns a Transliterator for mode. overrides replace or
+// extend the built-in transliterations, e.g. {"ä": "a"} for languages where
+// umlauts are plain accents; each key must be a single character. Keys are
+// matched case-insensitively. They do not apply in UnicodeKeep mode.
+func NewTransliterator(mode UnicodeMode, overrides map[string]string) (*Transliterator, error) {
+	t := &Transliterator{mode: mode, table: make(map[rune]string)}
+	for _, table := range []map[string]string{defaultTransliterations, overrides} {
+		for from, to := range table {
+			r, size := utf8.DecodeRuneInString(from)
+			if r == utf8.RuneError || size != len(from) {
+				return nil, fmt.Errorf("invalid transliteration %q: must be a single character", from)
+			}
+			t.table[unicode.ToUpper(r)] = capitalize(to)
+			t.table[unicode.ToLower(r)] = strings.ToLower(to) // wins for letters without a distinct capital, like ß
+		}
+	}
+	return t, nil
+}
+
+// Apply normalizes text to NFC and, unless the mode is UnicodeKeep,
+// transliterates it. Characters that are dropped become spaces, so that they
+// still separate words.
+func (t *Transliterator) Apply(text string) string {
+	runes := []rune(norm.NFC.String(text))
+	if t.mode == UnicodeKeep {
+		return string(runes)
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if to, ok := t.table[r]; ok {
+			// Keep words in capitals: "ÄRGER" -> "AERGER", not "AeRGER".
+			if unicode.IsUpper(r) && (i+1 < len(runes) && unicode.IsUpper(runes[i+1]) || i > 0 && unicode.IsUpper(runes[i-1])) {
+				to = strings.ToUpper(to)
+			}
+			b.WriteString(to)
+			continue
+		}
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+			continue
+		}
+		if ascii, ok := foldASCII(r); ok {
+			b.WriteString(ascii)
+			continue
+		}
+		if t.mode == UnicodeTranslit && (unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.M, r)) {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// ApplyFields returns a copy of fields with every value transliterated. It
+// fails with ErrNoASCII if a value with letters or digits has none left, as
+// happens to text in other scripts in UnicodeASCII mode; there is no
+// romanization.
+func (t *Transliterator) ApplyFields(fields map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(fields))
+	for k, v := range fields {
+		out[k] = t.Apply(v)
+		if hasAlphanumeric(v) && !hasAlphanumeric(out[k]) {
+			return nil, fmt.Errorf("field %q (%q) %w; use --unicode translit or keep", k, v, ErrNoASCII)
+		}
+	}
+	return out, nil
+}
+
+func hasAlphanumeric(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) >= 0
+}
+
+// foldASCII returns the ASCII form of r after compatibility decomposition
+// without its combining marks, e.g. "e" for "é" and "fi" for "ﬁ". It reports
+// false if r has none.
+func foldASCII(r rune) (string, bool) {
+	var b strings.Builder
+	for _, d := range norm.NFKD.String(string(r)) {
+		switch {
+		case unicode.Is(unicode.Mn, d):
+		case d < utf8.RuneSelf:
+			b.WriteRune(d)
+		default:
+			return "", false
+		}
+	}
+	return b.String(), b.Len() > 0
+}
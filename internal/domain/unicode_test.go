@@ -0,0 +1,92 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+func TestTransliterator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		mode      domain.UnicodeMode
+		overrides map[string]string
+		style     domain.Style
+		input     string
+		want      string
+	}{
+		{"umlauts and sharp s", domain.UnicodeTranslit, nil, domain.StyleKebab, "Müller Straße", "mueller-strasse"},
+		{"capitals", domain.UnicodeTranslit, nil, domain.StyleTitle, "Übersicht ÄRGER", "Uebersicht-Aerger"},
+		{"capitals preserved", domain.UnicodeTranslit, nil, domain.StylePreserve, "ÄRGER Öl", "AERGER-Oel"},
+		{"accents stripped", domain.UnicodeTranslit, nil, domain.StyleKebab, "Café Crème Brûlée", "cafe-creme-brulee"},
+		{"special letters", domain.UnicodeTranslit, nil, domain.StyleKebab, "Łódź Ørsted Þór", "lodz-orsted-thor"},
+		{"compatibility forms", domain.UnicodeTranslit, nil, domain.StyleKebab, "ﬁnal Ｒｅｐｏｒｔ", "final-report"},
+		{"decomposed input", domain.UnicodeTranslit, nil, domain.StyleKebab, "Mu\u0308ller", "mueller"},
+		{"CJK kept", domain.UnicodeTranslit, nil, domain.StyleKebab, "請求書 Müller", "請求書-mueller"},
+		{"cyrillic kept", domain.UnicodeTranslit, nil, domain.StyleSnake, "Счёт Фактура", "счёт_фактура"},
+		{"CJK removed in ascii", domain.UnicodeASCII, nil, domain.StyleKebab, "請求書 Müller", "mueller"},
+		{"symbols separate words", domain.UnicodeASCII, nil, domain.StyleKebab, "Preis–Liste€2024", "preis-liste-2024"},
+		{"keep normalizes", domain.UnicodeKeep, nil, domain.StyleKebab, "Mu\u0308ller Stra\u00dfe", "m\u00fcller-stra\u00dfe"},
+		{"keep CJK", domain.UnicodeKeep, nil, domain.StylePreserve, "請求書 2024", "請求書-2024"},
+		{"override", domain.UnicodeTranslit, map[string]string{"ä": "a", "&": "and"}, domain.StyleKebab, "Mäkelä & Co", "makela-and-co"},
+		{"separators collapse", domain.UnicodeTranslit, nil, domain.StylePreserve, "Budget -- Report _ 2024", "Budget-Report-2024"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tr, err := domain.NewTransliterator(tc.mode, tc.overrides)
+			if err != nil {
+				t.Fatalf("NewTransliterator() error = %v", err)
+			}
+			if got := tc.style.FormatWords(tr.Apply(tc.input)); got != tc.want {
+				t.Errorf("%s.FormatWords(Apply(%q)) = %q, want %q", tc.style, tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewTransliterator_InvalidOverride(t *testing.T) {
+	t.Parallel()
+
+	if _, err := domain.NewTransliterator(domain.UnicodeTranslit, map[string]string{"ae": "a"}); err == nil {
+		t.Error("NewTransliterator() accepted a key of two characters")
+	}
+}
+
+func TestParseUnicodeMode(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]domain.UnicodeMode{"": domain.UnicodeTranslit, "ASCII": domain.UnicodeASCII, "keep": domain.UnicodeKeep} {
+		if got, err := domain.ParseUnicodeMode(input); err != nil || got != want {
+			t.Errorf("ParseUnicodeMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := domain.ParseUnicodeMode("latin"); err == nil {
+		t.Error("ParseUnicodeMode(\"latin\") succeeded")
+	}
+}
+
+func TestTransliterator_ApplyFieldsNoASCII(t *testing.T) {
+	t.Parallel()
+
+	ascii, _ := domain.NewTransliterator(domain.UnicodeASCII, nil)
+	_, err := ascii.ApplyFields(map[string]string{"date": "2024-03-24", "subject": "請求書"})
+	if !errors.Is(err, domain.ErrNoASCII) || !strings.Contains(err.Error(), `"subject"`) {
+		t.Errorf("ApplyFields() error = %v, want %v for subject", err, domain.ErrNoASCII)
+	}
+
+	fields, err := ascii.ApplyFields(map[string]string{"subject": "請求書 2024", "note": ""})
+	if err != nil || strings.TrimSpace(fields["subject"]) != "2024" {
+		t.Errorf("ApplyFields() = %q, %v; want the digits kept", fields, err)
+	}
+
+	translit, _ := domain.NewTransliterator(domain.UnicodeTranslit, nil)
+	if fields, err := translit.ApplyFields(map[string]string{"subject": "請求書"}); err != nil || fields["subject"] != "請求書" {
+		t.Errorf("ApplyFields() = %q, %v; want CJK kept", fields, err)
+	}
+}
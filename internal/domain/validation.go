@@ -68,3 +68,30 @@ func IsAllowedMimeType(mimeType string) error {
 
 	return fmt.Errorf("unsupported file type: %s. Supported categories: Documents, Images, Video, Audio", mimeType)
 }
+
+// MatchesMimeType reports whether mimeType matches one of the patterns. Patterns
+// are either exact types ("application/pdf") or a whole category ("image/*").
+// An empty pattern list matches everything.
+func MatchesMimeType(mimeType string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	baseMime := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if category, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(baseMime, category+"/") {
+				return true
+			}
+		} else if pattern == baseMime {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTextMimeType reports whether content of this type is plain text that can be
+// passed to a model as a string.
+func IsTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || strings.HasPrefix(mimeType, "application/json") || strings.Contains(mimeType, "xml")
+}
@@ -46,3 +46,32 @@ func TestIsAllowedMimeType(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchesMimeType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mimeType string
+		patterns []string
+		want     bool
+	}{
+		{"no patterns", "application/pdf", nil, true},
+		{"exact", "application/pdf", []string{"application/pdf"}, true},
+		{"exact with params", "text/plain; charset=utf-8", []string{"text/plain"}, true},
+		{"wildcard", "image/png", []string{"application/pdf", "image/*"}, true},
+		{"case insensitive pattern", "image/png", []string{"IMAGE/*"}, true},
+		{"no match", "video/mp4", []string{"application/pdf", "image/*"}, false},
+		{"wildcard needs full category", "imagex/png", []string{"image/*"}, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := domain.MatchesMimeType(tt.mimeType, tt.patterns); got != tt.want {
+				t.Errorf("MatchesMimeType(%q, %v) = %v, want %v", tt.mimeType, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package ports declares the interfaces through which the application talks to
+// the outside world. Adapters in internal/adapters implement them.
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+// FileSystem handles OS-level operations
+type FileSystem interface {
+	ReadFile(path string) ([]byte, error)
+	// Rename never replaces an existing file: if newPath exists, it fails
+	// with an error matching os.ErrExist.
+	Rename(oldPath, newPath string) error
+	// Replace moves oldPath to newPath and replaces newPath if it exists.
+	Replace(oldPath, newPath string) error
+	Exists(path string) bool
+	GetMimeType(path string) (string, error)
+	// HashFile returns the domain.ContentHash of the file's content.
+	HashFile(path string) (string, error)
+	ModTime(path string) (time.Time, error)
+	MkdirAll(dir string) error
+}
+
+// AIProvider handles interaction with the LLM
+type AIProvider interface {
+	// GenerateName accepts raw content and mimeType to support multimodal inputs (PDFs, images, ...)
+	// and returns a value for every requested template field.
+	GenerateName(ctx context.Context, req domain.NameRequest) (domain.NameSuggestion, error)
+}
+
+// UI handles interaction with the user
+type UI interface {
+	Info(msg string)
+	PrintDetectedType(path, mimeType string)
+	PrintAnalyzing(path string)
+	PrintProposal(result domain.RenameResult)
+	// PrintCollision reports that the name a file wanted was taken and the
+	// proposal was changed to result.ProposedName.
+	PrintCollision(result domain.RenameResult, taken string)
+	PrintReviewTable(results []domain.RenameResult)
+	PrintRenamed(result domain.RenameResult)
+	PrintDryRun()
+	PrintCancelled()
+	Confirm(question string) (bool, error)
+	Ask(question string) (string, error)
+	// PickCandidate lets the user choose one of the candidate names for file and
+	// returns its index, or -1 to leave the file as it is.
+	PickCandidate(file string, candidates []domain.Candidate) (int, error)
+	Error(msg string)
+}
+
+// Journal records applied renames so that they can be undone later
+type Journal interface {
+	Record(result domain.RenameResult) error
+}
+
+// Cache remembers suggestions so that the same request is not sent twice
+type Cache interface {
+	Get(req domain.NameRequest) (domain.NameSuggestion, bool)
+	Put(req domain.NameRequest, suggestion domain.NameSuggestion) error
+}
+
+// Editor lets the user edit text, e.g. in $EDITOR
+type Editor interface {
+	Edit(text string) (string, error)
+}
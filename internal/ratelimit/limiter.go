@@ -0,0 +1,117 @@
+// Package ratelimit provides a token-bucket limiter for budgeting AI requests.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter enforces a requests-per-minute and a tokens-per-minute budget using two
+// token buckets. Both buckets start full, so short bursts up to the per-minute
+// budget are allowed. A zero budget disables the corresponding limit.
+type Limiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+	now      func() time.Time
+}
+
+// New creates a Limiter. requestsPerMinute and tokensPerMinute of 0 mean unlimited.
+func New(requestsPerMinute, tokensPerMinute int) *Limiter {
+	return newWithClock(requestsPerMinute, tokensPerMinute, time.Now)
+}
+
+func newWithClock(requestsPerMinute, tokensPerMinute int, now func() time.Time) *Limiter {
+	start := now()
+	return &Limiter{
+		requests: newBucket(requestsPerMinute, start),
+		tokens:   newBucket(tokensPerMinute, start),
+		now:      now,
+	}
+}
+
+// Wait blocks until one request costing the given number of tokens fits into the
+// budget, or until ctx is done. Requests larger than the whole per-minute token
+// budget are clamped to it so that they can eventually proceed.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	for {
+		delay := l.reserve(tokens)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve consumes the budget and returns 0 if it is available, otherwise it
+// returns how long to wait before trying again.
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.requests.refill(now)
+	l.tokens.refill(now)
+
+	delay := max(l.requests.wait(1), l.tokens.wait(float64(tokens)))
+	if delay > 0 {
+		return delay
+	}
+	l.requests.take(1)
+	l.tokens.take(float64(tokens))
+	return 0
+}
+
+// bucket is a single token bucket refilled continuously at perMinute/60 per second.
+type bucket struct {
+	capacity float64
+	level    float64
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{capacity: float64(perMinute), level: float64(perMinute), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b == nil {
+		return
+	}
+	elapsed := now.Sub(b.last).Minutes()
+	if elapsed > 0 {
+		b.level = min(b.capacity, b.level+elapsed*b.capacity)
+		b.last = now
+	}
+}
+
+// wait returns how long until n units are available. It never exceeds the time
+// needed to fill the bucket completely.
+func (b *bucket) wait(n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	n = min(n, b.capacity)
+	if b.level >= n {
+		return 0
+	}
+	missing := n - b.level
+	return max(time.Duration(missing/b.capacity*float64(time.Minute)), time.Millisecond)
+}
+
+func (b *bucket) take(n float64) {
+	if b == nil {
+		return
+	}
+	b.level -= min(n, b.capacity)
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Reserve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		rpm       int
+		tpm       int
+		calls     []int // tokens per call, all at the same instant
+		wantDelay []bool
+	}{
+		{
+			name:      "unlimited",
+			calls:     []int{1000, 1000, 1000},
+			wantDelay: []bool{false, false, false},
+		},
+		{
+			name:      "requests per minute exhausted",
+			rpm:       2,
+			calls:     []int{1, 1, 1},
+			wantDelay: []bool{false, false, true},
+		},
+		{
+			name:      "tokens per minute exhausted",
+			tpm:       1000,
+			calls:     []int{600, 300, 200},
+			wantDelay: []bool{false, false, true},
+		},
+		{
+			name:      "oversized request is clamped to budget",
+			tpm:       1000,
+			calls:     []int{5000, 1},
+			wantDelay: []bool{false, true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			now := time.Unix(0, 0)
+			l := newWithClock(tc.rpm, tc.tpm, func() time.Time { return now })
+			for i, tokens := range tc.calls {
+				got := l.reserve(tokens) > 0
+				if got != tc.wantDelay[i] {
+					t.Errorf("call %d: delayed = %v, want %v", i, got, tc.wantDelay[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	l := newWithClock(60, 0, func() time.Time { return now })
+	for i := 0; i < 60; i++ {
+		if d := l.reserve(0); d != 0 {
+			t.Fatalf("call %d delayed by %v within initial burst", i, d)
+		}
+	}
+
+	d := l.reserve(0)
+	if d <= 0 || d > time.Second {
+		t.Fatalf("expected delay of at most 1s after burst, got %v", d)
+	}
+
+	now = now.Add(time.Second)
+	if d := l.reserve(0); d != 0 {
+		t.Errorf("expected request to pass after refill, got delay %v", d)
+	}
+}
+
+func TestLimiter_WaitCancelled(t *testing.T) {
+	t.Parallel()
+
+	l := New(1, 0)
+	if err := l.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
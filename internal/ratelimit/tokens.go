@@ -0,0 +1,31 @@
+package ratelimit
+
+import (
+	"strings"
+
+	"github.com/maltehedderich/rename-ai/internal/domain"
+)
+
+const (
+	// promptOverheadTokens approximates the system prompt, schema and response.
+	promptOverheadTokens = 500
+	// imageTokens is what Gemini charges for a single image (or PDF page).
+	imageTokens = 258
+	// binaryBytesPerToken is a rough density for PDFs, audio and video, whose
+	// real cost depends on page count or duration rather than byte size.
+	binaryBytesPerToken = 1024
+)
+
+// EstimateTokens returns a rough upper-bound estimate of the tokens a naming
+// request will consume for the given content. It is used for rate limiting before
+// the request is sent, so it only needs to be in the right order of magnitude.
+func EstimateTokens(content []byte, mimeType string) int {
+	switch {
+	case domain.IsTextMimeType(mimeType):
+		return promptOverheadTokens + len(content)/4
+	case strings.HasPrefix(mimeType, "image/"):
+		return promptOverheadTokens + imageTokens
+	default:
+		return promptOverheadTokens + max(imageTokens, len(content)/binaryBytesPerToken)
+	}
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		size     int
+		mimeType string
+		want     int
+	}{
+		{"text", 4000, "text/plain; charset=utf-8", promptOverheadTokens + 1000},
+		{"json", 400, "application/json", promptOverheadTokens + 100},
+		{"image", 5 << 20, "image/png", promptOverheadTokens + imageTokens},
+		{"small pdf", 1024, "application/pdf", promptOverheadTokens + imageTokens},
+		{"large video", 10 << 20, "video/mp4", promptOverheadTokens + 10240},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := EstimateTokens(make([]byte, tc.size), tc.mimeType)
+			if got != tc.want {
+				t.Errorf("EstimateTokens() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}